@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"epicstyle/internal/analyzer"
 	"epicstyle/internal/fixer"
@@ -22,8 +24,26 @@ func main() {
 	levelFlag := flag.Int("level", 1, "Verification level (1=basic, 2=advanced)")
 	fixFlag := flag.Bool("fix", false, "Automatically fix violations")
 	dryRunFlag := flag.Bool("dry-run", false, "Show what would be fixed without applying changes")
+	interactiveFlag := flag.Bool("interactive", false, "Confirm each fix before applying it (with -fix)")
+	fixOnlyFlag := flag.String("fix-only", "", "Comma-separated rules whose fixes are applied (default: all)")
+	diffFlag := flag.Bool("diff", false, "Show the fixes as a unified diff")
+	patchFlag := flag.String("patch", "", "Write the fixes to a patch file for git apply (implies -dry-run without -fix)")
+	backupFlag := flag.String("backup", fixer.BackupNone, "Backup of fixed files: none, orig (file.c.orig) or journal (undone with 'undo')")
+	configFlag := flag.String("config", "", "Path to a JSON configuration file (default: "+types.ConfigFilename+" if present)")
+	projectFlag := flag.Bool("project", false, "Compare files with each other: unused, duplicate and undefined functions")
+	metricsFlag := flag.Bool("metrics", false, "Only output the per-function metrics table")
+	sortFlag := flag.String("sort", "complexity", "Metrics table sort column ("+strings.Join(reporter.MetricColumns, ", ")+")")
 	flag.Parse()
 
+	// Restore the files of the last fix session
+	if flag.NArg() > 0 && flag.Arg(0) == "undo" {
+		if err := runUndo(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Get path from flag or argument
 	path := *pathFlag
 	if path == "" && len(flag.Args()) > 0 {
@@ -32,17 +52,48 @@ func main() {
 
 	if path == "" {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] <file_or_directory>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s undo\n", os.Args[0])
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
 	// Run analysis
 	a := analyzer.NewAnalyzer(*levelFlag)
+	cfg, err := loadConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	a.SetConfig(cfg)
+	a.SetCrossFile(*projectFlag)
 
 	// Handle fix mode
-	if *fixFlag || *dryRunFlag {
-		f := fixer.NewFixer(a, *dryRunFlag)
-		if err := runFixer(f, path, *verboseFlag); err != nil {
+	if *fixFlag || *dryRunFlag || *patchFlag != "" {
+		f := fixer.NewFixer(a, *dryRunFlag || !*fixFlag)
+		if err := f.SetBackup(*backupFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := f.SetOnly(strings.Split(*fixOnlyFlag, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -fix-only: %v\n", err)
+			os.Exit(1)
+		}
+		opts := fixOptions{
+			verbose: *verboseFlag,
+			diff:    *diffFlag,
+			color:   isTerminal(os.Stdout),
+			patch:   *patchFlag,
+		}
+		if *interactiveFlag && !f.IsDryRun() {
+			if isTerminal(os.Stdin) {
+				p := newPrompter(os.Stdin, opts.color)
+				f.SetConfirm(p.confirm)
+				opts.interactive = true
+			} else {
+				fmt.Fprintf(os.Stderr, "%sStandard input is not a terminal: applying fixes without confirmation%s\n", types.ColorYellow, types.ColorReset)
+			}
+		}
+		if err := runFixer(f, path, opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -55,6 +106,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Handle metrics mode
+	if *metricsFlag {
+		if err := outputMetrics(report, *sortFlag, *jsonFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Handle silent mode
 	if *silentFlag {
 		if report.TotalViolations > 0 {
@@ -76,34 +136,90 @@ func main() {
 	}
 }
 
+// loadConfig reads the configuration file given on the command line, or
+// the default one from the working directory when it exists
+func loadConfig(path string) (*types.Config, error) {
+	if path == "" {
+		if _, err := os.Stat(types.ConfigFilename); err != nil {
+			return types.DefaultConfig(), nil
+		}
+		path = types.ConfigFilename
+	}
+	return types.LoadConfig(path)
+}
+
 // outputJSON prints the report in JSON format
 func outputJSON(report *types.Report) {
 	output, _ := json.MarshalIndent(report, "", "  ")
 	fmt.Println(string(output))
 }
 
-// runFixer runs the fixer on the given path
-func runFixer(f *fixer.Fixer, path string, verbose bool) error {
-	// Get list of C files to fix
-	files, err := types.CollectCFiles(path)
+// outputMetrics prints the metrics table, or its rows as JSON
+func outputMetrics(report *types.Report, column string, asJSON bool) error {
+	if !asJSON {
+		return reporter.PrintMetrics(report, column)
+	}
+	rows, err := reporter.SortMetrics(report, column)
 	if err != nil {
 		return err
 	}
+	output, _ := json.MarshalIndent(rows, "", "  ")
+	fmt.Println(string(output))
+	return nil
+}
+
+// fixOptions controls the output of the fixer
+type fixOptions struct {
+	verbose     bool
+	diff        bool   // print the unified diff of every file
+	color       bool   // colorize the diff
+	patch       string // file receiving the patch of every fix
+	interactive bool   // every fix was confirmed on the terminal
+}
 
-	if len(files) == 0 {
+// runFixer runs the fixer on the given path
+func runFixer(f *fixer.Fixer, path string, opts fixOptions) error {
+	// Fix the C files, renames updating the references of the project
+	results, err := f.FixProject(path)
+	if err != nil {
+		if len(results) == 0 {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Error fixing %v\n", err)
+	}
+
+	if len(results) == 0 {
 		fmt.Println("No C files found to fix")
 		return nil
 	}
 
 	totalFixes := 0
 	filesModified := 0
+	filesRefused := 0
+	totalDeclined := 0
+	var fixed, introduced, remaining int
+	var patch strings.Builder
 
-	// Process each file
-	for _, file := range files {
-		result, err := f.FixFile(file)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error fixing %s: %v\n", file, err)
-			continue
+	// Report each file
+	for _, result := range results {
+		for _, warning := range result.Warnings {
+			location := ""
+			if warning.Line > 0 {
+				location = fmt.Sprintf(" Line %d:", warning.Line)
+			}
+			fmt.Printf("%s%s: skipped [%s]%s %s%s\n", types.ColorYellow, result.Filename, warning.Rule, location, warning.Description, types.ColorReset)
+		}
+
+		totalDeclined += len(result.Declined)
+		if v := result.Verification; v != nil {
+			fixed += v.Fixed()
+			introduced += v.Introduced()
+			remaining += v.After
+		}
+
+		if result.Refused {
+			filesRefused++
+			printRefused(result, opts.verbose)
 		}
 
 		if len(result.Fixes) > 0 {
@@ -113,7 +229,7 @@ func runFixer(f *fixer.Fixer, path string, verbose bool) error {
 			}
 
 			// Print fixes
-			if verbose || f.IsDryRun() {
+			if opts.verbose || f.IsDryRun() {
 				fmt.Printf("\n%s%s%s\n", types.ColorBlue, result.Filename, types.ColorReset)
 				for _, fix := range result.Fixes {
 					mode := "Fixed"
@@ -126,26 +242,57 @@ func runFixer(f *fixer.Fixer, path string, verbose bool) error {
 						fmt.Printf("  %s [%s] %s\n", mode, fix.Rule, fix.Description)
 					}
 				}
+				printVerification(result.Verification, opts.verbose)
+			}
+
+			// Print the changes as a diff
+			diff := result.Patch()
+			patch.WriteString(diff)
+			if opts.diff && diff != "" {
+				if opts.color {
+					diff = fixer.ColorizeDiff(diff)
+				}
+				fmt.Printf("\n%s", diff)
 			}
 
 			// Handle file rename
 			if result.NewFilename != "" {
 				if !f.IsDryRun() {
-					if err := os.Rename(file, result.NewFilename); err != nil {
-						fmt.Fprintf(os.Stderr, "Error renaming %s to %s: %v\n", file, result.NewFilename, err)
-					} else if verbose {
+					if err := f.Rename(result.Path, result.NewFilename); err != nil {
+						fmt.Fprintf(os.Stderr, "Error renaming %s to %s: %v\n", result.Path, result.NewFilename, err)
+					} else if opts.verbose {
 						fmt.Printf("  Renamed: %s -> %s\n", result.Filename, filepath.Base(result.NewFilename))
 					}
-				} else if verbose {
+				} else if opts.verbose {
 					fmt.Printf("  Would rename: %s -> %s\n", result.Filename, filepath.Base(result.NewFilename))
 				}
 			}
 		}
 	}
 
+	if opts.patch != "" {
+		if err := os.WriteFile(opts.patch, []byte(patch.String()), 0644); err != nil {
+			return err
+		}
+	}
+
 	// Print summary
 	fmt.Printf("\n%sSummary:%s\n", types.ColorBold, types.ColorReset)
-	fmt.Printf("  Files processed: %d\n", len(files))
+	fmt.Printf("  Files processed: %d\n", len(results))
+	if opts.patch != "" {
+		fmt.Printf("  Patch written to: %s\n", opts.patch)
+	}
+	if f.Verifies() {
+		fmt.Printf("  Violations fixed: %d\n", fixed)
+		fmt.Printf("  Violations introduced: %d\n", introduced)
+		fmt.Printf("  Violations remaining: %d\n", remaining)
+	}
+	if opts.interactive {
+		fmt.Printf("  Fixes declined: %d\n", totalDeclined)
+	}
+	if filesRefused > 0 {
+		fmt.Printf("  %sFiles left unchanged (fixes added violations): %d%s\n", types.ColorRed, filesRefused, types.ColorReset)
+	}
 	if f.IsDryRun() {
 		fmt.Printf("  Fixes available: %d\n", totalFixes)
 		if totalFixes > 0 {
@@ -154,6 +301,9 @@ func runFixer(f *fixer.Fixer, path string, verbose bool) error {
 	} else {
 		fmt.Printf("  Files modified: %d\n", filesModified)
 		fmt.Printf("  Total fixes applied: %d\n", totalFixes)
+		if session := f.Session(); session != "" {
+			fmt.Printf("  Backup: %s (run '%s undo' to revert)\n", session, filepath.Base(os.Args[0]))
+		}
 		if totalFixes > 0 {
 			fmt.Printf("\n%s✓ Auto-fix complete%s\n", types.ColorGreen, types.ColorReset)
 		}
@@ -161,3 +311,120 @@ func runFixer(f *fixer.Fixer, path string, verbose bool) error {
 
 	return nil
 }
+
+// runUndo restores the files of the last fix session
+func runUndo() error {
+	restored, err := fixer.Undo()
+	for _, file := range restored {
+		fmt.Printf("  Restored: %s\n", file)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%s✓ Last fix session undone (%d files)%s\n", types.ColorGreen, len(restored), types.ColorReset)
+	return nil
+}
+
+// printVerification prints how the fixes of a file changed its violations,
+// rule by rule in verbose mode
+func printVerification(v *fixer.Verification, verbose bool) {
+	if v == nil {
+		return
+	}
+	fmt.Printf("  Violations: %d -> %d (fixed %d, introduced %d)\n", v.Before, v.After, v.Fixed(), v.Introduced())
+	if !v.Converged {
+		fmt.Printf("  %sFixes still changed the file after %d passes%s\n", types.ColorYellow, v.Iterations, types.ColorReset)
+	}
+	if !verbose {
+		return
+	}
+	for _, d := range v.Rules {
+		fmt.Printf("    [%s] fixed %d, introduced %d, remaining %d\n", d.Rule, d.Fixed(), d.Introduced(), d.After)
+	}
+}
+
+// printRefused reports a file whose fixes were discarded because they
+// added violations
+func printRefused(result *fixer.FixResult, verbose bool) {
+	v := result.Verification
+	fmt.Printf("\n%s%s%s\n", types.ColorBlue, result.Filename, types.ColorReset)
+	fmt.Printf("  %sLeft unchanged: the fixes would raise the violations from %d to %d%s\n", types.ColorRed, v.Before, v.After, types.ColorReset)
+	if !verbose {
+		return
+	}
+	for _, d := range v.Rules {
+		if d.Introduced() > 0 {
+			fmt.Printf("    [%s] introduced %d\n", d.Rule, d.Introduced())
+		}
+	}
+}
+
+// prompter asks on the terminal whether each fix is applied
+type prompter struct {
+	in    *bufio.Reader
+	color bool
+	file  string          // file of the last proposal, printed once per group
+	shown bool            // a proposal was shown
+	rules map[string]bool // rules whose fixes are all accepted
+	quit  bool            // every remaining fix is declined
+}
+
+// newPrompter creates a prompter reading the answers from in
+func newPrompter(in *os.File, color bool) *prompter {
+	return &prompter{in: bufio.NewReader(in), color: color, rules: make(map[string]bool)}
+}
+
+// confirm shows a proposed fix with its diff and reads the answer: yes,
+// no, all the fixes of the rule, or quit
+func (p *prompter) confirm(proposal fixer.Proposal) bool {
+	if p.quit {
+		return false
+	}
+	if p.rules[proposal.Rule] {
+		return true
+	}
+
+	if !p.shown || proposal.Filename != p.file {
+		name := proposal.Filename
+		if name == "" {
+			name = "Project"
+		}
+		fmt.Printf("\n%s%s%s\n", types.ColorBlue, name, types.ColorReset)
+		p.file, p.shown = proposal.Filename, true
+	}
+	if proposal.Line > 0 {
+		fmt.Printf("  [%s] Line %d: %s\n", proposal.Rule, proposal.Line, proposal.Description)
+	} else {
+		fmt.Printf("  [%s] %s\n", proposal.Rule, proposal.Description)
+	}
+	if proposal.Diff != "" {
+		diff := proposal.Diff
+		if p.color {
+			diff = fixer.ColorizeDiff(diff)
+		}
+		fmt.Print(diff)
+	}
+
+	for {
+		fmt.Printf("Apply this fix? [y]es, [n]o, [a]ll %s fixes, [q]uit: ", proposal.Rule)
+		answer, err := p.in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "a", "all":
+			p.rules[proposal.Rule] = true
+			return true
+		case "q", "quit":
+			p.quit = true
+			return false
+		}
+		if err != nil {
+			fmt.Println()
+			fmt.Fprintf(os.Stderr, "%sEnd of standard input: the remaining fixes are declined%s\n", types.ColorYellow, types.ColorReset)
+			p.quit = true
+			return false
+		}
+	}
+}
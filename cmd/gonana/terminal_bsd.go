@@ -0,0 +1,8 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import "syscall"
+
+// ioctlGetTermios reads the terminal attributes of a file descriptor
+const ioctlGetTermios = syscall.TIOCGETA
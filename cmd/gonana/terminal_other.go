@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package main
+
+import "os"
+
+// isTerminal reports false where terminals cannot be detected, so that
+// fixes are applied without confirmation and output is not colored
+func isTerminal(file *os.File) bool {
+	return false
+}
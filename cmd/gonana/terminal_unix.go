@@ -0,0 +1,18 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal reports whether the file is a terminal: reading its terminal
+// attributes fails for pipes, regular files and other devices such as
+// /dev/null
+func isTerminal(file *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(ioctlGetTermios), uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
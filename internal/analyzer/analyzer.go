@@ -3,23 +3,31 @@ package analyzer
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"epicstyle/internal/parser"
 	"epicstyle/internal/rules"
 	"epicstyle/internal/types"
 )
 
 // Analyzer analyzes C source files for style violations
 type Analyzer struct {
-	level int
-	rules map[string]types.Rule
+	level        int
+	rules        map[string]types.Rule
+	projectRules map[string]types.ProjectRule
+	config       *types.Config
+	project      map[string]bool
+	crossFile    bool
 }
 
 // NewAnalyzer creates a new analyzer with the specified verification level
 func NewAnalyzer(level int) *Analyzer {
 	a := &Analyzer{
-		level: level,
-		rules: make(map[string]types.Rule),
+		level:        level,
+		rules:        make(map[string]types.Rule),
+		projectRules: make(map[string]types.ProjectRule),
+		config:       types.DefaultConfig(),
 	}
 	a.initRules()
 	return a
@@ -30,29 +38,54 @@ func (a *Analyzer) Level() int {
 	return a.level
 }
 
+// Config returns the configuration rules are checked with
+func (a *Analyzer) Config() *types.Config {
+	return a.config
+}
+
+// SetConfig replaces the configuration rules are checked with
+func (a *Analyzer) SetConfig(cfg *types.Config) {
+	a.config = cfg
+}
+
+// SetCrossFile enables the project-wide phase comparing the analyzed
+// files with each other (unused, duplicate and undefined functions)
+func (a *Analyzer) SetCrossFile(enabled bool) {
+	a.crossFile = enabled
+}
+
 // Rules returns the rule map
 func (a *Analyzer) Rules() map[string]types.Rule {
 	return a.rules
 }
 
+// ProjectRules returns the rules checking the analyzed tree as a whole
+func (a *Analyzer) ProjectRules() map[string]types.ProjectRule {
+	return a.projectRules
+}
+
 // initRules initializes all checking rules based on verification level
 func (a *Analyzer) initRules() {
 	// Level 1 rules (basic)
 	a.rules["C-L1"] = types.Rule{
-		Code: "C-L1", Name: "Line Length", Description: "Line too long (80 chars max)",
+		Code: "C-L1", Name: "Line Length", Description: "Line too long (80 columns max)",
 		Severity: "major", Level: 1, Check: rules.CheckLineLength,
+		Fix: rules.FixLineLength,
 	}
 	a.rules["C-L2"] = types.Rule{
 		Code: "C-L2", Name: "Empty Lines", Description: "Forbidden empty lines",
 		Severity: "minor", Level: 1, Check: rules.CheckEmptyLines,
+		Fix: rules.FixEmptyLines,
 	}
 	a.rules["C-L3"] = types.Rule{
 		Code: "C-L3", Name: "Indentation", Description: "TAB indentation only",
 		Severity: "major", Level: 1, Check: rules.CheckIndentation,
+		Fix: rules.FixIndentation,
 	}
 	a.rules["C-L4"] = types.Rule{
 		Code: "C-L4", Name: "Variable Declaration", Description: "One variable per line",
 		Severity: "major", Level: 1, Check: rules.CheckVariableDeclaration,
+		Fix: rules.FixVariableDeclaration,
 	}
 	a.rules["C-V1"] = types.Rule{
 		Code: "C-V1", Name: "Variable Position", Description: "Variables at function start",
@@ -61,34 +94,132 @@ func (a *Analyzer) initRules() {
 	a.rules["C-O1"] = types.Rule{
 		Code: "C-O1", Name: "Filename", Description: "Filename in snake_case",
 		Severity: "major", Level: 1, Check: rules.CheckFilename,
+		Fix: rules.FixFilename,
 	}
 	a.rules["C-O2"] = types.Rule{
-		Code: "C-O2", Name: "Function Count", Description: "Max 3 functions per file",
+		Code: "C-O2", Name: "Function Count", Description: "Max 10 functions (5 non-static) per file",
 		Severity: "major", Level: 1, Check: rules.CheckFunctionCount,
 	}
 	a.rules["C-F1"] = types.Rule{
 		Code: "C-F1", Name: "Function Name", Description: "Function name in snake_case",
 		Severity: "major", Level: 1, Check: rules.CheckFunctionNames,
+		Fix: rules.FixFunctionNames,
 	}
 	a.rules["C-F2"] = types.Rule{
 		Code: "C-F2", Name: "Macro Name", Description: "Macro in SCREAMING_SNAKE_CASE",
 		Severity: "major", Level: 1, Check: rules.CheckMacroNames,
+		Fix: rules.FixMacroNames,
+	}
+	a.rules["C-P1"] = types.Rule{
+		Code: "C-P1", Name: "Multi-line Macro", Description: "Macros fit on a single line",
+		Severity: "major", Level: 1, Check: rules.CheckMultilineMacros,
+	}
+	a.rules["C-P2"] = types.Rule{
+		Code: "C-P2", Name: "Macro Statements", Description: "At most one statement per macro",
+		Severity: "major", Level: 1, Check: rules.CheckMacroStatements,
 	}
 	a.rules["C-F3"] = types.Rule{
 		Code: "C-F3", Name: "Function Length", Description: "Function max 25 lines",
 		Severity: "major", Level: 1, Check: rules.CheckFunctionLength,
 	}
+	a.rules["C-L6"] = types.Rule{
+		Code: "C-L6", Name: "Statement Per Line", Description: "One statement per line",
+		Severity: "major", Level: 1, Check: rules.CheckStatementPerLine,
+	}
+	a.rules["C-L7"] = types.Rule{
+		Code: "C-L7", Name: "Line Breaks", Description: "One empty line after declarations and between functions",
+		Severity: "minor", Level: 1, Check: rules.CheckLineBreaks,
+	}
+	a.rules["C-V2"] = types.Rule{
+		Code: "C-V2", Name: "Identifier Naming", Description: "Identifiers follow the configured naming patterns",
+		Severity: "major", Level: 1, Check: rules.CheckIdentifierNames,
+	}
+	a.rules["C-V3"] = types.Rule{
+		Code: "C-V3", Name: "Pointer Declaration", Description: "Asterisk attached to the identifier",
+		Severity: "minor", Level: 1, Check: rules.CheckPointerDeclarations,
+		Fix: rules.FixPointerDeclarations,
+	}
+	a.rules["C-B1"] = types.Rule{
+		Code: "C-B1", Name: "Forbidden Functions", Description: "Only allowed functions and headers",
+		Severity: "major", Level: 1, Check: rules.CheckForbiddenFunctions,
+	}
+
+	// Makefile rules
+	a.rules["C-MK1"] = types.Rule{
+		Code: "C-MK1", Name: "Makefile Rules", Description: "NAME, all, clean, fclean, re and $(NAME) rules",
+		Severity: "major", Level: 1, Kind: types.KindMakefile, Check: rules.CheckMakefileTargets,
+	}
+	a.rules["C-MK2"] = types.Rule{
+		Code: "C-MK2", Name: "Makefile Phony", Description: "Non-file targets declared .PHONY",
+		Severity: "minor", Level: 1, Kind: types.KindMakefile, Check: rules.CheckMakefilePhony,
+	}
+	a.rules["C-MK3"] = types.Rule{
+		Code: "C-MK3", Name: "Makefile Wildcards", Description: "Sources listed explicitly",
+		Severity: "major", Level: 1, Kind: types.KindMakefile, Check: rules.CheckMakefileWildcards,
+	}
+	a.rules["C-MK4"] = types.Rule{
+		Code: "C-MK4", Name: "Makefile Header", Description: "Epitech header",
+		Severity: "minor", Level: 1, Kind: types.KindMakefile, Check: rules.CheckMakefileHeader,
+	}
+	a.rules["C-MK5"] = types.Rule{
+		Code: "C-MK5", Name: "Makefile Relink", Description: "No relink",
+		Severity: "major", Level: 1, Kind: types.KindMakefile, Check: rules.CheckMakefileRelink,
+	}
+
+	// Repository rules, run when a directory is analyzed
+	a.projectRules["C-O3"] = types.ProjectRule{
+		Code: "C-O3", Name: "Unwanted Files", Description: "No binaries, objects or temporary files",
+		Severity: "major", Level: 1, Check: rules.CheckUnwantedFiles,
+	}
+	a.projectRules["C-O4"] = types.ProjectRule{
+		Code: "C-O4", Name: "Directory Name", Description: "Directory names in snake_case",
+		Severity: "minor", Level: 1, Check: rules.CheckDirectoryNames,
+	}
+	a.projectRules["C-O5"] = types.ProjectRule{
+		Code: "C-O5", Name: "Source Placement", Description: ".c files in the source directories",
+		Severity: "minor", Level: 1, Check: rules.CheckSourcePlacement,
+	}
+
+	// Cross-file rules, run when the project phase is enabled
+	a.projectRules["C-X1"] = types.ProjectRule{
+		Code: "C-X1", Name: "Unused Function", Description: "Non-static functions used by other files",
+		Severity: "minor", Level: 1, CrossFile: true, Check: rules.CheckUnusedFunctions,
+	}
+	a.projectRules["C-X2"] = types.ProjectRule{
+		Code: "C-X2", Name: "Duplicate Definition", Description: "Symbols defined once across the project",
+		Severity: "major", Level: 1, CrossFile: true, Check: rules.CheckDuplicateDefinitions,
+	}
+	a.projectRules["C-X3"] = types.ProjectRule{
+		Code: "C-X3", Name: "Orphan Prototype", Description: "Header prototypes have a definition",
+		Severity: "minor", Level: 1, CrossFile: true, Check: rules.CheckOrphanPrototypes,
+	}
+
+	a.rules["C-E1"] = types.Rule{
+		Code: "C-E1", Name: "Line Endings", Description: "LF line endings only",
+		Severity: "minor", Level: 1, Check: rules.CheckLineEndings,
+		Fix: rules.FixLineEndings,
+	}
+	a.rules["C-E2"] = types.Rule{
+		Code: "C-E2", Name: "Final Newline", Description: "File ends with a newline",
+		Severity: "minor", Level: 1, Check: rules.CheckFinalNewline,
+		Fix: rules.FixFinalNewline,
+	}
 
 	// Level 2 rules (advanced)
 	if a.level >= 2 {
 		a.rules["C-C1"] = types.Rule{
 			Code: "C-C1", Name: "Comment Format", Description: "/* */ comments only",
 			Severity: "minor", Level: 2, Check: rules.CheckCommentFormat,
+			Fix: rules.FixCommentFormat,
 		}
 		a.rules["C-C2"] = types.Rule{
 			Code: "C-C2", Name: "Function Comment", Description: "Function comment required",
 			Severity: "minor", Level: 2, Check: rules.CheckFunctionComment,
 		}
+		a.rules["C-C3"] = types.Rule{
+			Code: "C-C3", Name: "Function Body Comment", Description: "No comments inside functions",
+			Severity: "minor", Level: 2, Check: rules.CheckFunctionBodyComments,
+		}
 		a.rules["C-G1"] = types.Rule{
 			Code: "C-G1", Name: "Global Variables", Description: "No non-const globals",
 			Severity: "major", Level: 2, Check: rules.CheckGlobalVariables,
@@ -100,6 +231,38 @@ func (a *Analyzer) initRules() {
 		a.rules["C-L5"] = types.Rule{
 			Code: "C-L5", Name: "For Loop Declaration", Description: "No declaration in for loops",
 			Severity: "major", Level: 2, Check: rules.CheckForLoopDeclaration,
+			Fix: rules.FixForLoopDeclaration,
+		}
+		a.rules["C-P3"] = types.Rule{
+			Code: "C-P3", Name: "Directive Indentation", Description: "Directives indented per #if nesting level",
+			Severity: "minor", Level: 2, Check: rules.CheckDirectiveIndentation,
+		}
+		a.rules["C-F5"] = types.Rule{
+			Code: "C-F5", Name: "Explicit Void", Description: "Empty parameter lists written (void)",
+			Severity: "major", Level: 2, Check: rules.CheckExplicitVoid,
+			Fix: rules.FixExplicitVoid,
+		}
+		a.rules["C-F6"] = types.Rule{
+			Code: "C-F6", Name: "Structure By Value", Description: "Structures passed by pointer",
+			Severity: "major", Level: 2, Check: rules.CheckStructByValue,
+		}
+		a.rules["C-F7"] = types.Rule{
+			Code: "C-F7", Name: "Function Complexity", Description: "Complexity, nesting and statements under the thresholds",
+			Severity: "minor", Level: 2, Check: rules.CheckFunctionMetrics,
+		}
+		a.rules["C-M1"] = types.Rule{
+			Code: "C-M1", Name: "Magic Numbers", Description: "No unnamed numeric constants",
+			Severity: "minor", Level: 2, Check: rules.CheckMagicNumbers,
+		}
+		a.rules["C-E3"] = types.Rule{
+			Code: "C-E3", Name: "Encoding", Description: "UTF-8 without byte order mark",
+			Severity: "minor", Level: 2, Check: rules.CheckEncoding,
+			Fix: rules.FixEncoding,
+		}
+		a.rules["C-E4"] = types.Rule{
+			Code: "C-E4", Name: "Inline Tabs", Description: "No tab characters after the indentation",
+			Severity: "minor", Level: 2, Check: rules.CheckMidLineTabs,
+			Fix: rules.FixMidLineTabs,
 		}
 	}
 }
@@ -115,12 +278,34 @@ func (a *Analyzer) AnalyzePath(path string) (*types.Report, error) {
 		Files: make([]types.FileResult, 0, len(files)),
 	}
 
+	// Functions defined in any analyzed file are not external symbols
+	a.project = indexProject(files)
+	defer func() { a.project = nil }()
+
+	project := &types.ProjectAnalysis{Files: files, Config: a.config}
+	analyzed := make([]string, 0, len(files))
 	for _, file := range files {
-		result, err := a.AnalyzeFile(file)
+		result, analysis, err := a.analyzeFile(file)
 		if err != nil {
 			continue
 		}
 		report.Files = append(report.Files, *result)
+		analyzed = append(analyzed, file)
+		if types.FileKind(file) == types.KindC {
+			project.Sources = append(project.Sources, analysis)
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		project.Root = path
+		project.Tree = collectTree(path)
+		report.Repository = a.checkProjectRules(project, false)
+	}
+	if a.crossFile {
+		a.attachViolations(report, analyzed, a.checkProjectRules(project, true))
+	}
+
+	for _, result := range report.Files {
 		report.TotalFiles++
 		report.TotalLines += result.LineCount
 		report.TotalViolations += len(result.Violations)
@@ -128,6 +313,7 @@ func (a *Analyzer) AnalyzePath(path string) (*types.Report, error) {
 			report.CleanFiles++
 		}
 	}
+	report.TotalViolations += len(report.Repository)
 
 	// Calculate total score
 	if report.TotalFiles > 0 {
@@ -138,9 +324,128 @@ func (a *Analyzer) AnalyzePath(path string) (*types.Report, error) {
 		report.TotalScore = totalScore / float64(report.TotalFiles)
 	}
 
+	report.ExternalSymbols = a.summarizeSymbols(report.Files)
+
 	return report, nil
 }
 
+// attachViolations adds cross-file violations to the result of the file
+// they name and updates its score. files holds the path of each result.
+func (a *Analyzer) attachViolations(report *types.Report, files []string, violations []types.Violation) {
+	index := make(map[string]int)
+	for i, file := range files {
+		index[file] = i
+	}
+
+	for _, v := range violations {
+		i, ok := index[v.File]
+		if !ok {
+			report.Repository = append(report.Repository, v)
+			continue
+		}
+		v.File = ""
+		report.Files[i].Violations = append(report.Files[i].Violations, v)
+	}
+	for i := range report.Files {
+		report.Files[i].Score = a.CalculateScore(report.Files[i].Violations)
+	}
+}
+
+// collectTree lists the files and directories below root. Hidden entries
+// (.git, editor settings...) are skipped along with their content.
+func collectTree(root string) []types.TreeEntry {
+	var tree []types.TreeEntry
+	filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == root {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		tree = append(tree, types.TreeEntry{Path: filepath.ToSlash(rel), Dir: info.IsDir()})
+		return nil
+	})
+	return tree
+}
+
+// checkProjectRules runs the repository rules, or the cross-file ones, of
+// the current level in code order so that the report is stable
+func (a *Analyzer) checkProjectRules(project *types.ProjectAnalysis, crossFile bool) []types.Violation {
+	codes := make([]string, 0, len(a.projectRules))
+	for code := range a.projectRules {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var violations []types.Violation
+	for _, code := range codes {
+		if rule := a.projectRules[code]; rule.Level <= a.level && rule.CrossFile == crossFile {
+			violations = append(violations, rule.Check(project)...)
+		}
+	}
+	return violations
+}
+
+// indexProject collects the functions and macros defined by the given files
+func indexProject(files []string) map[string]bool {
+	symbols := make(map[string]bool)
+	for _, file := range files {
+		if types.FileKind(file) != types.KindC {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, name := range rules.DefinedSymbols(parser.ParseSource(string(content))) {
+			symbols[name] = true
+		}
+	}
+	return symbols
+}
+
+// summarizeSymbols aggregates the external symbols used by every file
+func (a *Analyzer) summarizeSymbols(files []types.FileResult) []types.SymbolUsage {
+	usages := make(map[string]*types.SymbolUsage)
+	for _, file := range files {
+		for _, ref := range file.External {
+			key := ref.Kind + " " + ref.Name
+			usage, ok := usages[key]
+			if !ok {
+				usage = &types.SymbolUsage{
+					Name:      ref.Name,
+					Kind:      ref.Kind,
+					Forbidden: a.config.IsForbidden(ref.Kind, ref.Name),
+				}
+				usages[key] = usage
+			}
+			usage.Count++
+			if len(usage.Files) == 0 || usage.Files[len(usage.Files)-1] != file.Filename {
+				usage.Files = append(usage.Files, file.Filename)
+			}
+		}
+	}
+
+	summary := make([]types.SymbolUsage, 0, len(usages))
+	for _, usage := range usages {
+		summary = append(summary, *usage)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Kind != summary[j].Kind {
+			return summary[i].Kind > summary[j].Kind
+		}
+		return summary[i].Name < summary[j].Name
+	})
+	return summary
+}
+
 // collectFiles gathers all C source files from the given path
 // CollectFiles collects all C/H files from the given path
 func (a *Analyzer) CollectFiles(path string) ([]string, error) {
@@ -156,7 +461,10 @@ func (a *Analyzer) CollectFiles(path string) ([]string, error) {
 			if err != nil {
 				return err
 			}
-			if strings.HasSuffix(p, ".c") || strings.HasSuffix(p, ".h") {
+			if info.IsDir() && p != path && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if isAnalyzable(p) {
 				files = append(files, p)
 			}
 			return nil
@@ -164,43 +472,81 @@ func (a *Analyzer) CollectFiles(path string) ([]string, error) {
 		if err != nil {
 			return nil, err
 		}
-	} else if strings.HasSuffix(path, ".c") || strings.HasSuffix(path, ".h") {
+	} else if isAnalyzable(path) {
 		files = append(files, path)
 	}
 
 	return files, nil
 }
 
+// isAnalyzable reports whether a file is a C source, a header or a Makefile
+func isAnalyzable(path string) bool {
+	return strings.HasSuffix(path, ".c") || strings.HasSuffix(path, ".h") ||
+		types.FileKind(path) == types.KindMakefile
+}
+
 // AnalyzeFile analyzes a single file and returns its result
 func (a *Analyzer) AnalyzeFile(filename string) (*types.FileResult, error) {
+	result, _, err := a.analyzeFile(filename)
+	return result, err
+}
+
+// AnalyzeContent analyzes content as if it were the content of filename,
+// without reading the file
+func (a *Analyzer) AnalyzeContent(filename string, content []byte) *types.FileResult {
+	result, _ := a.analyzeContent(filename, content)
+	return result
+}
+
+// analyzeFile analyzes a single file and also returns its parsed content
+func (a *Analyzer) analyzeFile(filename string) (*types.FileResult, *types.FileAnalysis, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	result, analysis := a.analyzeContent(filename, content)
+	return result, analysis, nil
+}
 
-	lines := strings.Split(string(content), "\n")
+// analyzeContent runs the file rules on content read from filename
+func (a *Analyzer) analyzeContent(filename string, content []byte) (*types.FileResult, *types.FileAnalysis) {
+	lines := types.SplitLines(string(content))
 	analysis := &types.FileAnalysis{
-		Filename:  filename,
-		Lines:     lines,
-		Functions: types.ExtractFunctions(lines),
+		Filename: filename,
+		Content:  content,
+		Lines:    lines,
+		Config:   a.config,
+
+		ProjectSymbols: a.project,
+	}
+	kind := types.FileKind(filename)
+	if kind == types.KindC {
+		analysis.Functions = types.UnitFunctions(analysis.Unit())
 	}
 
 	violations := a.checkRules(analysis, filename)
+	a.markFixable(analysis, violations)
 	score := a.CalculateScore(violations)
 
-	return &types.FileResult{
+	result := &types.FileResult{
 		Filename:   filepath.Base(filename),
 		Violations: violations,
 		Score:      score,
 		LineCount:  len(lines),
-	}, nil
+	}
+	if kind == types.KindC {
+		result.External = rules.ExternalSymbols(analysis)
+		result.Metrics = rules.ComputeMetrics(analysis)
+	}
+	return result, analysis
 }
 
 // checkRules runs all applicable rules against the file
 func (a *Analyzer) checkRules(analysis *types.FileAnalysis, filename string) []types.Violation {
 	var violations []types.Violation
+	kind := types.FileKind(filename)
 	for _, rule := range a.rules {
-		if rule.Level <= a.level {
+		if rule.Level <= a.level && rule.Kind == kind {
 			ruleViolations := rule.Check(analysis, filename, 0)
 			violations = append(violations, ruleViolations...)
 		}
@@ -208,6 +554,30 @@ func (a *Analyzer) checkRules(analysis *types.FileAnalysis, filename string) []t
 	return violations
 }
 
+// markFixable flags the violations corrected by a fix of their rule's
+// provider, on their line or on the whole file
+func (a *Analyzer) markFixable(analysis *types.FileAnalysis, violations []types.Violation) {
+	fixed := make(map[string]map[int]bool)
+	for i := range violations {
+		v := &violations[i]
+		rule := a.rules[v.Rule]
+		if rule.Fix == nil {
+			continue
+		}
+		lines, ok := fixed[v.Rule]
+		if !ok {
+			lines = make(map[int]bool)
+			for _, fix := range rule.Fix(analysis) {
+				if fix.Fixes() {
+					lines[fix.Line] = true
+				}
+			}
+			fixed[v.Rule] = lines
+		}
+		v.Fixable = lines[v.Line] || lines[0]
+	}
+}
+
 // CalculateScore computes the file score based on violations
 func (a *Analyzer) CalculateScore(violations []types.Violation) float64 {
 	score := 100.0
@@ -0,0 +1,227 @@
+package fixer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backup modes of the files written by the fixer
+const (
+	BackupNone    = "none"    // no backup
+	BackupOrig    = "orig"    // copy next to the file with the .orig suffix
+	BackupJournal = "journal" // session journal under BackupDir, restored by Undo
+)
+
+// BackupDir holds one journal directory per fix session
+var BackupDir = filepath.Join(".gonana", "backups")
+
+// journalFilename lists the files of a session in its directory
+const journalFilename = "journal.json"
+
+// JournalEntry is a file modified or renamed during a fix session
+type JournalEntry struct {
+	Path    string      `json:"path"`              // absolute path before the fixes
+	Backup  string      `json:"backup"`            // copy of the original, relative to the session
+	Mode    os.FileMode `json:"mode"`              // permissions of the original
+	Renamed string      `json:"renamed,omitempty"` // absolute path after a C-O1 rename
+}
+
+// journal records the files of the current fix session
+type journal struct {
+	dir     string
+	entries []JournalEntry
+}
+
+// SetBackup selects how the original content of the written files is
+// kept: BackupNone, BackupOrig or BackupJournal
+func (f *Fixer) SetBackup(mode string) error {
+	switch mode {
+	case BackupNone, BackupOrig, BackupJournal:
+		f.backup = mode
+		return nil
+	}
+	return fmt.Errorf("unknown backup mode '%s' (expected %s, %s or %s)", mode, BackupNone, BackupOrig, BackupJournal)
+}
+
+// Session returns the journal directory of the current fix session, or ""
+// when nothing was recorded
+func (f *Fixer) Session() string {
+	if f.journal == nil {
+		return ""
+	}
+	return f.journal.dir
+}
+
+// saveOriginal keeps the original content of filename before it is
+// overwritten, as selected by the backup mode
+func (f *Fixer) saveOriginal(filename string, content []byte) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	switch f.backup {
+	case BackupOrig:
+		return writeFile(filename+".orig", content, info.Mode().Perm())
+	case BackupJournal:
+		_, err := f.record(filename, content, info.Mode().Perm())
+		return err
+	}
+	return nil
+}
+
+// record adds filename to the session journal, creating the session on
+// first use, and returns its entry. A file already recorded keeps its
+// first backup.
+func (f *Fixer) record(filename string, content []byte, mode os.FileMode) (*JournalEntry, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if f.journal == nil {
+		dir, err := newSessionDir()
+		if err != nil {
+			return nil, err
+		}
+		f.journal = &journal{dir: dir}
+	}
+	for i := range f.journal.entries {
+		if f.journal.entries[i].Path == abs {
+			return &f.journal.entries[i], nil
+		}
+	}
+
+	// Mirror the path relative to the working directory when possible
+	backup := strings.TrimLeft(patchPath(abs), "/")
+	backup = strings.ReplaceAll(backup, ":", "")
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(f.journal.dir, backup)), 0755); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(f.journal.dir, backup), content, mode); err != nil {
+		return nil, err
+	}
+
+	f.journal.entries = append(f.journal.entries, JournalEntry{Path: abs, Backup: backup, Mode: mode})
+	if err := f.journal.save(); err != nil {
+		return nil, err
+	}
+	return &f.journal.entries[len(f.journal.entries)-1], nil
+}
+
+// newSessionDir creates the directory of a new session, named after the
+// current time so that the last session sorts last
+func newSessionDir() (string, error) {
+	name := time.Now().Format("20060102-150405")
+	dir := filepath.Join(BackupDir, name)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		dir = filepath.Join(BackupDir, fmt.Sprintf("%s-%d", name, n))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// save writes the journal of the session, after every recorded file so
+// that an interrupted session can still be undone
+func (j *journal) save() error {
+	data, err := json.MarshalIndent(j.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(j.dir, journalFilename), data, 0644)
+}
+
+// Rename renames a fixed file, without replacing an existing one, recording the rename in the session
+// journal so that Undo can revert it
+func (f *Fixer) Rename(oldPath, newPath string) error {
+	if exists(newPath) && !sameFile(oldPath, newPath) {
+		return fmt.Errorf("%s already exists", newPath)
+	}
+	if f.backup == BackupJournal {
+		content, err := os.ReadFile(oldPath)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(oldPath)
+		if err != nil {
+			return err
+		}
+		entry, err := f.record(oldPath, content, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if entry.Renamed, err = filepath.Abs(newPath); err != nil {
+			return err
+		}
+		if err := f.journal.save(); err != nil {
+			return err
+		}
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// LastSession returns the directory of the most recent fix session
+// recorded under BackupDir
+func LastSession() (string, error) {
+	dirs, err := os.ReadDir(BackupDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	var sessions []string
+	for _, d := range dirs {
+		if d.IsDir() {
+			sessions = append(sessions, d.Name())
+		}
+	}
+	if len(sessions) == 0 {
+		return "", fmt.Errorf("no fix session to undo in %s", BackupDir)
+	}
+	sort.Strings(sessions)
+	return filepath.Join(BackupDir, sessions[len(sessions)-1]), nil
+}
+
+// Undo restores the files of the most recent fix session, renames
+// included, then deletes the session. It returns the restored paths.
+func Undo() ([]string, error) {
+	dir, err := LastSession()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, journalFilename))
+	if err != nil {
+		return nil, err
+	}
+	var entries []JournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid journal %s: %v", dir, err)
+	}
+
+	var restored []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Backup)))
+		if err != nil {
+			return restored, err
+		}
+		if entry.Renamed != "" {
+			if err := os.Remove(entry.Renamed); err != nil && !os.IsNotExist(err) {
+				return restored, err
+			}
+		}
+		if err := writeFile(entry.Path, content, entry.Mode); err != nil {
+			return restored, err
+		}
+		restored = append(restored, entry.Path)
+	}
+	return restored, os.RemoveAll(dir)
+}
@@ -0,0 +1,81 @@
+package fixer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Proposal is a fix submitted for confirmation before it is applied
+type Proposal struct {
+	Fix
+	Filename string // file the fix applies to, "" for a project-wide rename
+	Diff     string // hunk showing the change, "" when it is not made of lines
+}
+
+// confirmation holds the decisions taken on the fixes of the file being
+// fixed. Declined fixes are proposed again by every pass run; they are
+// counted so that the same change is only asked about once.
+type confirmation struct {
+	declined map[string]int // declined changes, by key
+	seen     map[string]int // changes proposed during the current pass run
+}
+
+// SetConfirm installs a function deciding whether each fix is applied.
+// Without one, every fix is.
+func (f *Fixer) SetConfirm(confirm func(Proposal) bool) {
+	f.confirm = confirm
+}
+
+// accept submits a fix turning the lines before, starting at line, into
+// the lines after. An accepted fix is recorded in the result.
+func (f *Fixer) accept(result *FixResult, fix Fix, line int, before, after []string) bool {
+	if f.confirm != nil {
+		key := fix.Rule + "\x00" + strings.Join(before, "\n") + "\x00" + strings.Join(after, "\n")
+		c := &f.confirmation
+		c.seen[key]++
+		if c.seen[key] <= c.declined[key] {
+			return false
+		}
+
+		proposal := Proposal{Fix: fix, Filename: result.Filename}
+		if before != nil || after != nil {
+			proposal.Diff = hunk(line, before, after)
+		}
+		if !f.confirm(proposal) {
+			c.declined[key]++
+			result.Declined = append(result.Declined, fix)
+			return false
+		}
+	}
+	result.Fixes = append(result.Fixes, fix)
+	return true
+}
+
+// resetConfirmation forgets the decisions taken on the previous file
+func (f *Fixer) resetConfirmation() {
+	f.confirmation = confirmation{declined: make(map[string]int)}
+}
+
+// newPassRun starts counting the changes proposed by a run of the passes
+func (f *Fixer) newPassRun() {
+	if f.confirmation.declined == nil {
+		f.resetConfirmation()
+	}
+	f.confirmation.seen = make(map[string]int)
+}
+
+// hunk formats the replacement of lines starting at line as a diff hunk
+func hunk(line int, before, after []string) string {
+	if line < 1 {
+		line = 1
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(line, len(before)), hunkRange(line, len(after)))
+	for _, l := range before {
+		out.WriteString("-" + l + "\n")
+	}
+	for _, l := range after {
+		out.WriteString("+" + l + "\n")
+	}
+	return out.String()
+}
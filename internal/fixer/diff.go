@@ -0,0 +1,261 @@
+package fixer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"epicstyle/internal/types"
+)
+
+// DiffContext is the number of unchanged lines shown around each change
+const DiffContext = 3
+
+// diffOp is a line of an edit script: ' ' kept, '-' removed, '+' added
+type diffOp struct {
+	Kind byte
+	Text string
+}
+
+// splitKeepingNewlines splits text into lines that keep their "\n", so
+// that a missing final newline is a difference like any other
+func splitKeepingNewlines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, computed
+// with Myers' algorithm after trimming the common prefix and suffix
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myers computes the edit script of two sequences without common ends
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace back from the end, collecting the script in reverse
+	var reversed []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	ops := make([]diffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+// hunkRange formats the start and length of a hunk side. An empty side
+// starts at the line preceding it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// writeHunks writes the hunks of an edit script with context lines
+func writeHunks(out *strings.Builder, ops []diffOp) {
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		start := i - DiffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].Kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*DiffContext {
+				break
+			}
+		}
+		stop := end + DiffContext
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.Kind != '+' {
+				oldStart++
+			}
+			if op.Kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:stop] {
+			if op.Kind != '+' {
+				oldCount++
+			}
+			if op.Kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, op := range ops[start:stop] {
+			out.WriteByte(op.Kind)
+			out.WriteString(op.Text)
+			if !strings.HasSuffix(op.Text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+}
+
+// UnifiedDiff returns a git-style patch turning before into after. The
+// paths are slash-separated and relative to the directory the patch is
+// applied from; different paths describe a rename. It returns "" when
+// nothing changes.
+func UnifiedDiff(oldPath, newPath, before, after string) string {
+	if oldPath == newPath && before == after {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "diff --git a/%s b/%s\n", oldPath, newPath)
+	if oldPath != newPath {
+		fmt.Fprintf(&out, "rename from %s\nrename to %s\n", oldPath, newPath)
+	}
+	if before != after {
+		fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", oldPath, newPath)
+		writeHunks(&out, diffLines(splitKeepingNewlines(before), splitKeepingNewlines(after)))
+	}
+	return out.String()
+}
+
+// patchPath returns the slash-separated path of a file relative to the
+// working directory, where the patch is expected to be applied
+func patchPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// Patch returns the changes of the fix result, rename included, as a
+// patch applicable with git apply from the working directory
+func (r *FixResult) Patch() string {
+	newPath := r.Path
+	if r.NewFilename != "" {
+		newPath = r.NewFilename
+	}
+	return UnifiedDiff(patchPath(r.Path), patchPath(newPath), r.Original, r.Fixed)
+}
+
+// ColorizeDiff highlights the headers, hunk ranges, removed and added
+// lines of a patch for terminal output
+func ColorizeDiff(patch string) string {
+	var out strings.Builder
+	header := true
+	for _, line := range splitKeepingNewlines(patch) {
+		text := strings.TrimSuffix(line, "\n")
+		color := ""
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			header = true
+			color = types.ColorBold
+		case strings.HasPrefix(text, "@@"):
+			header = false
+			color = types.ColorCyan
+		case header:
+			color = types.ColorBold
+		case strings.HasPrefix(text, "-"):
+			color = types.ColorRed
+		case strings.HasPrefix(text, "+"):
+			color = types.ColorGreen
+		}
+		if color == "" {
+			out.WriteString(line)
+			continue
+		}
+		out.WriteString(color + text + types.ColorReset + "\n")
+	}
+	return out.String()
+}
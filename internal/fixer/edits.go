@@ -0,0 +1,269 @@
+package fixer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/analyzer"
+	"epicstyle/internal/types"
+)
+
+// fixOrder is the precedence of the rules whose fixes overlap: encoding
+// and line endings first, line splitting last as it depends on the final
+// text of the lines. Other rules come after, by code.
+var fixOrder = []string{
+	"C-E3", "C-E1", "C-L2", "C-L3", "C-L4", "C-V3", "C-F5",
+	"C-C1", "C-L5", "C-E4", "C-L1", "C-E2", "C-O1", "C-F1", "C-F2",
+}
+
+// registry returns the rules of every level, which fixes apply to
+// whatever the verification level
+func (f *Fixer) registry() map[string]types.Rule {
+	if f.rules == nil {
+		f.rules = analyzer.NewAnalyzer(2).Rules()
+	}
+	return f.rules
+}
+
+// SetOnly limits the fixes to those of the given rules, which must have a
+// fix provider. An empty list selects every rule.
+func (f *Fixer) SetOnly(codes []string) error {
+	only := make(map[string]bool)
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		rule, ok := f.registry()[code]
+		if !ok {
+			return fmt.Errorf("unknown rule '%s'", code)
+		}
+		if rule.Fix == nil {
+			return fmt.Errorf("rule %s has no automatic fix", code)
+		}
+		only[code] = true
+	}
+	f.only = nil
+	if len(only) > 0 {
+		f.only = only
+	}
+	return nil
+}
+
+// selected reports whether the fixes of a rule are applied
+func (f *Fixer) selected(code string) bool {
+	return f.only == nil || f.only[code]
+}
+
+// providers returns the selected rules fixing the files of path's kind,
+// by precedence
+func (f *Fixer) providers(path string) []types.Rule {
+	kind := types.FileKind(path)
+	var selected []types.Rule
+	for code, rule := range f.registry() {
+		if rule.Fix != nil && rule.Kind == kind && f.selected(code) {
+			selected = append(selected, rule)
+		}
+	}
+
+	rank := func(code string) int {
+		for i, c := range fixOrder {
+			if c == code {
+				return i
+			}
+		}
+		return len(fixOrder)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		ri, rj := rank(selected[i].Code), rank(selected[j].Code)
+		if ri != rj {
+			return ri < rj
+		}
+		return selected[i].Code < selected[j].Code
+	})
+	return selected
+}
+
+// provider returns a selected rule fixing the files of path's kind
+func (f *Fixer) provider(code, path string) (types.Rule, bool) {
+	rule, ok := f.registry()[code]
+	if !ok || rule.Fix == nil || rule.Kind != types.FileKind(path) || !f.selected(code) {
+		return types.Rule{}, false
+	}
+	return rule, true
+}
+
+// overlaps reports whether two edits touch the same text. An insertion
+// overlaps an edit starting or ending where it inserts.
+func overlaps(a, b types.Edit) bool {
+	if a.Start == a.End || b.Start == b.End {
+		return a.Start <= b.End && b.Start <= a.End
+	}
+	return a.Start < b.End && b.Start < a.End
+}
+
+// overlapsAny reports whether one of the edits overlaps an applied one
+func overlapsAny(edits, applied []types.Edit) bool {
+	for _, e := range edits {
+		for _, a := range applied {
+			if overlaps(e, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyEdits returns content with non-overlapping edits applied
+func applyEdits(content string, edits []types.Edit) string {
+	if len(edits) == 0 {
+		return content
+	}
+	sorted := append([]types.Edit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	pos := 0
+	for _, e := range sorted {
+		b.WriteString(content[pos:e.Start])
+		b.WriteString(e.Text)
+		pos = e.End
+	}
+	b.WriteString(content[pos:])
+	return b.String()
+}
+
+// changedLines returns the lines of content touched by edits, before and
+// after applying them, with the number of the first one
+func changedLines(content string, edits []types.Edit) (int, []string, []string) {
+	start, end := len(content), 0
+	for _, e := range edits {
+		start = min(start, e.Start)
+		end = max(end, e.End)
+	}
+	first := strings.LastIndexByte(content[:start], '\n') + 1
+	last := len(content)
+	if i := strings.IndexByte(content[end:], '\n'); i >= 0 {
+		last = end + i
+	}
+
+	shifted := make([]types.Edit, len(edits))
+	for i, e := range edits {
+		shifted[i] = types.Edit{Start: e.Start - first, End: e.End - first, Text: e.Text}
+	}
+	before := content[first:last]
+	after := applyEdits(before, shifted)
+	return strings.Count(content[:first], "\n") + 1, strings.Split(before, "\n"), strings.Split(after, "\n")
+}
+
+// trackedFix is a fix applied by a pass, with offsets kept up to date in
+// the content of the later passes: the start of its line and the text its
+// edits wrote
+type trackedFix struct {
+	fix        Fix
+	line       int
+	start, end int
+}
+
+// trackPass maps the fixes of the earlier passes through the fixes of a
+// pass, which turned before into after, and adds those of the pass. A fix
+// touching lines written by a fix of an earlier pass completes it and is
+// not added, so that every change is listed once.
+func trackPass(tracked []trackedFix, before, after string, fixes []Fix) []trackedFix {
+	var edits []types.Edit
+	for _, fix := range fixes {
+		edits = append(edits, fix.Edits...)
+	}
+	earlier := len(tracked)
+	for i := range tracked {
+		t := &tracked[i]
+		t.line = shiftOffset(edits, t.line)
+		t.start = shiftOffset(edits, t.start)
+		t.end = shiftOffset(edits, t.end)
+	}
+
+	for _, fix := range fixes {
+		t := trackedFix{fix: fix, line: lineOffset(before, fix.Line), start: len(before)}
+		last := types.Edit{Start: -1}
+		for _, e := range fix.Edits {
+			t.start = min(t.start, e.Start)
+			if e.Start > last.Start {
+				last = e
+			}
+		}
+		t.line = shiftOffset(edits, t.line)
+		t.start = shiftOffset(edits, t.start)
+		t.end = shiftOffset(edits, last.Start) + len(last.Text)
+
+		first, end := lineAt(after, t.start), lastLineAt(after, t.start, t.end)
+		completes := false
+		for _, prev := range tracked[:earlier] {
+			if first <= lastLineAt(after, prev.start, prev.end) && lineAt(after, prev.start) <= end {
+				completes = true
+				break
+			}
+		}
+		if !completes {
+			tracked = append(tracked, t)
+		}
+	}
+	return tracked
+}
+
+// trackedFixes returns the tracked fixes with the line numbers of the
+// final content
+func trackedFixes(tracked []trackedFix, content string) []Fix {
+	fixes := make([]Fix, 0, len(tracked))
+	for _, t := range tracked {
+		fix := t.fix
+		if fix.Line > 0 {
+			fix.Line = lineAt(content, t.line)
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes
+}
+
+// shiftOffset maps an offset of a content to the content with edits
+// applied; an offset inside an edit moves to the end of its text
+func shiftOffset(edits []types.Edit, offset int) int {
+	shifted := offset
+	for _, e := range edits {
+		switch {
+		case e.Start >= offset:
+		case e.End <= offset:
+			shifted += len(e.Text) - (e.End - e.Start)
+		default:
+			shifted += e.Start + len(e.Text) - offset
+		}
+	}
+	return shifted
+}
+
+// lineOffset returns the offset of the start of a 1-based line
+func lineOffset(content string, line int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(content[offset:], '\n')
+		if i < 0 {
+			return len(content)
+		}
+		offset += i + 1
+	}
+	return offset
+}
+
+// lineAt returns the 1-based line of an offset
+func lineAt(content string, offset int) int {
+	return strings.Count(content[:min(offset, len(content))], "\n") + 1
+}
+
+// lastLineAt returns the last line of the text between two offsets, the
+// line break ending it not counting as the start of another line
+func lastLineAt(content string, start, end int) int {
+	if end > start && end <= len(content) && content[end-1] == '\n' {
+		end--
+	}
+	return lineAt(content, end)
+}
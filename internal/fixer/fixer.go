@@ -1,11 +1,8 @@
 package fixer
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings"
 
 	"epicstyle/internal/analyzer"
 	"epicstyle/internal/types"
@@ -15,13 +12,23 @@ import (
 type Fixer struct {
 	analyzer *analyzer.Analyzer
 	dryRun   bool
+	backup   string
+	journal  *journal
+
+	rules map[string]types.Rule // every rule, see registry
+	only  map[string]bool       // rules selected by SetOnly, nil for all
+
+	confirm      func(Proposal) bool
+	confirmation confirmation
 }
 
-// NewFixer creates a new fixer instance
+// NewFixer creates a new fixer instance, which keeps no backup until
+// SetBackup selects one
 func NewFixer(a *analyzer.Analyzer, dryRun bool) *Fixer {
 	return &Fixer{
 		analyzer: a,
 		dryRun:   dryRun,
+		backup:   BackupNone,
 	}
 }
 
@@ -30,8 +37,45 @@ func (f *Fixer) IsDryRun() bool {
 	return f.dryRun
 }
 
-// FixFile attempts to fix violations in a file
+// Verifies returns whether the fixer analyzes the files before and after
+// fixing them
+func (f *Fixer) Verifies() bool {
+	return f.analyzer != nil
+}
+
+// settings returns the configuration of the analyzer, or the defaults when
+// the fixer runs without one
+func (f *Fixer) settings() *types.Config {
+	if f.analyzer == nil {
+		return types.DefaultConfig()
+	}
+	return f.analyzer.Config()
+}
+
+// MaxIterations bounds the number of times the fix passes run on a file
+// before its content stops changing
+const MaxIterations = 5
+
+// FixFile attempts to fix violations in a file. The fix passes run again
+// on their own output until it no longer changes, then the file is
+// analyzed before and after the fixes; it is not written when the fixes
+// increased the number of violations.
 func (f *Fixer) FixFile(filename string) (*FixResult, error) {
+	result, err := f.prepareFix(filename)
+	if err != nil {
+		return nil, err
+	}
+	checkRenames([]*FixResult{result})
+	f.verify(result)
+	if err := f.write(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// prepareFix computes the fixes of a file, without verifying or writing
+// them
+func (f *Fixer) prepareFix(filename string) (*FixResult, error) {
 	// Read the file
 	content, err := os.ReadFile(filename)
 	if err != nil {
@@ -39,299 +83,166 @@ func (f *Fixer) FixFile(filename string) (*FixResult, error) {
 	}
 
 	originalContent := string(content)
-	lines := strings.Split(originalContent, "\n")
 
 	// Track fixes applied
 	result := &FixResult{
-		Filename:      filepath.Base(filename),
-		OriginalLines: len(lines),
-		Fixes:         make([]Fix, 0),
+		Filename: filepath.Base(filename),
+		Path:     filename,
+		Original: originalContent,
+		Fixes:    make([]Fix, 0),
 	}
 
-	// Apply fixes
-	lines = f.fixEmptyLines(lines, result)
-	lines = f.fixIndentation(lines, result)
-	lines = f.fixMultipleVariableDeclarations(lines, result)
-	lines = f.fixCommentFormat(lines, result)
-	lines = f.fixForLoopDeclarations(lines, result)
-
-	// Join lines back
-	fixedContent := strings.Join(lines, "\n")
-
-	// Check if filename needs fixing
-	if f.shouldFixFilename(filename) {
-		newName := f.fixFilename(filename)
-		result.Fixes = append(result.Fixes, Fix{
-			Rule:        "C-O1",
-			Description: fmt.Sprintf("Rename file to %s", filepath.Base(newName)),
-			Line:        0,
-		})
-		result.NewFilename = newName
+	// Run the passes until a fixpoint or the iteration cap, the fixes
+	// being numbered from the final content
+	f.resetConfirmation()
+	fixedContent := originalContent
+	var tracked []trackedFix
+	iterations, converged := 0, false
+	for iterations < MaxIterations {
+		pass := &FixResult{Filename: result.Filename}
+		next := f.fixContent(filename, fixedContent, pass)
+		iterations++
+		if iterations == 1 {
+			result.OriginalLines = pass.OriginalLines
+		}
+		result.Warnings = pass.Warnings
+		result.Declined = append(result.Declined, pass.Declined...)
+		if next == fixedContent {
+			converged = true
+			break
+		}
+		tracked = trackPass(tracked, fixedContent, next, pass.Fixes)
+		result.FixedLines = pass.FixedLines
+		fixedContent = next
+	}
+	result.Fixes = trackedFixes(tracked, fixedContent)
+	if fixedContent == originalContent {
+		result.FixedLines = result.OriginalLines
 	}
 
-	// Only write if not dry run and content changed
-	if !f.dryRun && fixedContent != originalContent {
-		if err := os.WriteFile(filename, []byte(fixedContent), 0644); err != nil {
-			return nil, err
+	// Rename the file when its name is not in snake_case
+	if rule, ok := f.provider("C-O1", filename); ok {
+		for _, fix := range rule.Fix(&types.FileAnalysis{Filename: filename}) {
+			if fix.Rename != "" && f.accept(result, fix, 0, nil, nil) {
+				result.NewFilename = filepath.Join(filepath.Dir(filename), fix.Rename)
+			}
 		}
-		result.ModifiedContent = true
 	}
 
-	result.FixedLines = len(strings.Split(fixedContent, "\n"))
+	result.Fixed = fixedContent
+	result.iterations = iterations
+	result.converged = converged
 
 	return result, nil
 }
 
-// fixEmptyLines removes forbidden empty lines (C-L2)
-func (f *Fixer) fixEmptyLines(lines []string, result *FixResult) []string {
-	if len(lines) == 0 {
-		return lines
+// verify compares the violations of a file before and after its fixes,
+// and discards the fixes when they increased the number of violations
+func (f *Fixer) verify(result *FixResult) {
+	f.compare(result)
+	if result.Verification != nil && result.Verification.After > result.Verification.Before {
+		refuse(result)
 	}
+}
 
-	fixed := make([]string, 0, len(lines))
-
-	// Remove leading empty lines
-	startIdx := 0
-	for startIdx < len(lines) && strings.TrimSpace(lines[startIdx]) == "" {
-		result.Fixes = append(result.Fixes, Fix{
-			Rule:        "C-L2",
-			Description: "Removed empty line at beginning of file",
-			Line:        startIdx + 1,
-		})
-		startIdx++
-	}
-
-	// Process middle content - remove consecutive empty lines
-	prevEmpty := false
-	for i := startIdx; i < len(lines); i++ {
-		isEmpty := strings.TrimSpace(lines[i]) == ""
-
-		if isEmpty && prevEmpty {
-			// Skip consecutive empty line
-			result.Fixes = append(result.Fixes, Fix{
-				Rule:        "C-L2",
-				Description: "Removed consecutive empty line",
-				Line:        i + 1,
-			})
-			continue
-		}
+// refuse discards the fixes of a file, which is left unchanged
+func refuse(result *FixResult) {
+	result.Refused = true
+	result.Fixes = nil
+	result.NewFilename = ""
+	result.Fixed = result.Original
+}
 
-		fixed = append(fixed, lines[i])
-		prevEmpty = isEmpty
+// compare analyzes a file before and after its fixes
+func (f *Fixer) compare(result *FixResult) {
+	if f.analyzer == nil {
+		return
 	}
-
-	// Remove trailing empty lines
-	for len(fixed) > 0 && strings.TrimSpace(fixed[len(fixed)-1]) == "" {
-		result.Fixes = append(result.Fixes, Fix{
-			Rule:        "C-L2",
-			Description: "Removed empty line at end of file",
-			Line:        len(fixed),
-		})
-		fixed = fixed[:len(fixed)-1]
+	target := result.Path
+	if result.NewFilename != "" {
+		target = result.NewFilename
 	}
 
-	return fixed
+	before := f.analyzer.AnalyzeContent(result.Path, []byte(result.Original))
+	after := f.analyzer.AnalyzeContent(target, []byte(result.Fixed))
+	result.Verification = compareViolations(before.Violations, after.Violations)
+	result.Verification.Iterations = result.iterations
+	result.Verification.Converged = result.converged
 }
 
-// fixIndentation replaces leading spaces with tabs (C-L3)
-func (f *Fixer) fixIndentation(lines []string, result *FixResult) []string {
-	fixed := make([]string, len(lines))
-
-	for i, line := range lines {
-		if len(line) > 0 && line[0] == ' ' {
-			// Count leading spaces
-			spaceCount := 0
-			for _, r := range line {
-				if r == ' ' {
-					spaceCount++
-				} else {
-					break
-				}
-			}
-
-			// Replace with tabs (assuming 4 spaces = 1 tab)
-			tabCount := spaceCount / 4
-			remainder := spaceCount % 4
-
-			fixed[i] = strings.Repeat("\t", tabCount) + strings.Repeat(" ", remainder) + line[spaceCount:]
-
-			result.Fixes = append(result.Fixes, Fix{
-				Rule:        "C-L3",
-				Description: fmt.Sprintf("Replaced %d spaces with %d tabs", spaceCount, tabCount),
-				Line:        i + 1,
-			})
-		} else {
-			fixed[i] = line
-		}
+// write saves the fixed content of a file, unless in dry run mode or
+// when nothing changed
+func (f *Fixer) write(result *FixResult) error {
+	if f.dryRun || result.Fixed == result.Original {
+		return nil
 	}
-
-	return fixed
-}
-
-// fixMultipleVariableDeclarations splits multiple declarations (C-L4)
-func (f *Fixer) fixMultipleVariableDeclarations(lines []string, result *FixResult) []string {
-	fixed := make([]string, 0, len(lines))
-
-	varDeclRegex := regexp.MustCompile(`^\s*(int|char|float|double|long|short|unsigned)\s+([a-zA-Z_][a-zA-Z0-9_]*\s*,\s*)+([a-zA-Z_][a-zA-Z0-9_]*)\s*;`)
-
-	for i, line := range lines {
-		// Skip lines in for loops
-		if strings.Contains(line, "for") {
-			fixed = append(fixed, line)
-			continue
-		}
-
-		if varDeclRegex.MatchString(line) {
-			// Extract type and variables
-			trimmed := strings.TrimSpace(line)
-			parts := strings.Fields(trimmed)
-
-			if len(parts) >= 2 {
-				varType := parts[0]
-				// Get the rest and remove semicolon
-				varsStr := strings.TrimSuffix(strings.Join(parts[1:], " "), ";")
-				vars := strings.Split(varsStr, ",")
-
-				// Get indentation
-				indent := ""
-				for _, r := range line {
-					if r == '\t' || r == ' ' {
-						indent += string(r)
-					} else {
-						break
-					}
-				}
-
-				// Create separate declarations
-				for _, v := range vars {
-					v = strings.TrimSpace(v)
-					fixed = append(fixed, indent+varType+" "+v+";")
-				}
-
-				result.Fixes = append(result.Fixes, Fix{
-					Rule:        "C-L4",
-					Description: fmt.Sprintf("Split multiple variable declarations into %d lines", len(vars)),
-					Line:        i + 1,
-				})
-				continue
-			}
-		}
-
-		fixed = append(fixed, line)
+	if err := f.saveOriginal(result.Path, []byte(result.Original)); err != nil {
+		return err
 	}
-
-	return fixed
-}
-
-// fixCommentFormat converts // comments to /* */ (C-C1)
-func (f *Fixer) fixCommentFormat(lines []string, result *FixResult) []string {
-	fixed := make([]string, len(lines))
-
-	for i, line := range lines {
-		if strings.Contains(line, "//") {
-			// Find the // comment
-			idx := strings.Index(line, "//")
-			before := line[:idx]
-			comment := strings.TrimSpace(line[idx+2:])
-
-			if comment == "" {
-				fixed[i] = strings.TrimRight(before, " \t")
-			} else {
-				fixed[i] = before + "/* " + comment + " */"
-			}
-
-			result.Fixes = append(result.Fixes, Fix{
-				Rule:        "C-C1",
-				Description: "Converted // comment to /* */",
-				Line:        i + 1,
-			})
-		} else {
-			fixed[i] = line
-		}
+	if err := writeFile(result.Path, []byte(result.Fixed), 0644); err != nil {
+		return err
 	}
-
-	return fixed
+	result.ModifiedContent = true
+	return nil
 }
 
-// fixForLoopDeclarations extracts variable declarations from for loops (C-L5)
-func (f *Fixer) fixForLoopDeclarations(lines []string, result *FixResult) []string {
-	fixed := make([]string, 0, len(lines)*2)
-
-	forDeclRegex := regexp.MustCompile(`^\s*for\s*\(\s*(int|char|float|double)\s+([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*([^;]+);(.*)$`)
-
-	for i, line := range lines {
-		matches := forDeclRegex.FindStringSubmatch(line)
-		if len(matches) >= 5 {
-			// Extract indentation
-			indent := ""
-			for _, r := range line {
-				if r == '\t' || r == ' ' {
-					indent += string(r)
-				} else {
-					break
+// fixContent runs the fix providers once over content and applies their
+// fixes, a fix overlapping one of a rule of higher precedence being left
+// to the next run. File and symbol renames are left to the caller.
+func (f *Fixer) fixContent(path, content string, result *FixResult) string {
+	f.newPassRun()
+	analysis := &types.FileAnalysis{
+		Filename: path,
+		Content:  []byte(content),
+		Lines:    types.SplitLines(content),
+		Config:   f.settings(),
+	}
+	analysis.Functions = types.UnitFunctions(analysis.Unit())
+	result.OriginalLines = len(analysis.Lines)
+
+	var edits []types.Edit
+	for _, rule := range f.providers(path) {
+		for _, fix := range rule.Fix(analysis) {
+			switch {
+			case fix.Rename != "":
+				continue
+			case !fix.Fixes():
+				result.Warnings = append(result.Warnings, fix)
+			case overlapsAny(fix.Edits, edits):
+				continue
+			default:
+				line, before, after := changedLines(content, fix.Edits)
+				if f.accept(result, fix, line, before, after) {
+					edits = append(edits, fix.Edits...)
 				}
 			}
-
-			varType := matches[1]
-			varName := matches[2]
-			initValue := strings.TrimSpace(matches[3])
-			rest := matches[4]
-
-			// Add variable declaration
-			fixed = append(fixed, indent+varType+" "+varName+";")
-			fixed = append(fixed, "")
-
-			// Add modified for loop
-			forLoop := indent + "for (" + varName + " = " + initValue + ";" + rest
-			fixed = append(fixed, forLoop)
-
-			result.Fixes = append(result.Fixes, Fix{
-				Rule:        "C-L5",
-				Description: "Extracted variable declaration from for loop",
-				Line:        i + 1,
-			})
-			continue
 		}
-
-		fixed = append(fixed, line)
 	}
 
+	fixed := applyEdits(content, edits)
+	result.FixedLines = len(types.SplitLines(fixed))
 	return fixed
 }
 
-// shouldFixFilename checks if filename needs fixing (C-O1)
-func (f *Fixer) shouldFixFilename(filename string) bool {
-	base := filepath.Base(filename)
-	name := strings.TrimSuffix(base, filepath.Ext(base))
-	return !types.IsSnakeCase(name)
-}
-
-// fixFilename converts filename to snake_case (C-O1)
-func (f *Fixer) fixFilename(filename string) string {
-	dir := filepath.Dir(filename)
-	base := filepath.Base(filename)
-	ext := filepath.Ext(base)
-	name := strings.TrimSuffix(base, ext)
-
-	// Convert to snake_case
-	snakeName := types.ToSnakeCase(name)
-
-	return filepath.Join(dir, snakeName+ext)
-}
-
-// Fix represents a single fix applied
-type Fix struct {
-	Rule        string
-	Description string
-	Line        int
-}
+// Fix is a fix applied, or left undone, with the rule it corrects
+type Fix = types.Fix
 
 // FixResult contains the results of fixing a file
 type FixResult struct {
 	Filename        string
+	Path            string // path of the file as given to FixFile
+	Original        string // content before the fixes
+	Fixed           string // content after the fixes
 	OriginalLines   int
 	FixedLines      int
 	Fixes           []Fix
+	Warnings        []Fix // violations left unfixed on purpose
+	Declined        []Fix // fixes refused when asked for confirmation
 	ModifiedContent bool
 	NewFilename     string
+	Verification    *Verification // nil when the fixer runs without analyzer
+	Refused         bool          // fixes discarded because they added violations
+
+	iterations int  // fix passes run
+	converged  bool // the last pass left the content unchanged
 }
@@ -1,6 +1,7 @@
 package fixer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,37 @@ import (
 	"epicstyle/internal/types"
 )
 
+// fixRule applies the fixes of a single rule to the content of a file
+func fixRule(t *testing.T, code, path, content string, result *FixResult) string {
+	t.Helper()
+	fixer := NewFixer(nil, true)
+	if err := fixer.SetOnly([]string{code}); err != nil {
+		t.Fatal(err)
+	}
+	return fixer.fixContent(path, content, result)
+}
+
+// fixLines applies the fixes of a single rule to the lines of a C file
+func fixLines(t *testing.T, code string, lines []string, result *FixResult) []string {
+	t.Helper()
+	return types.SplitLines(fixRule(t, code, "test.c", strings.Join(lines, "\n")+"\n", result))
+}
+
+// fixRename returns the names C-O1 renames a file to
+func fixRename(t *testing.T, path string) []string {
+	t.Helper()
+	fixer := NewFixer(nil, true)
+	rule, ok := fixer.provider("C-O1", path)
+	if !ok {
+		t.Fatal("no C-O1 fix provider")
+	}
+	var renames []string
+	for _, fix := range rule.Fix(&types.FileAnalysis{Filename: path}) {
+		renames = append(renames, fix.Rename)
+	}
+	return renames
+}
+
 func TestFixEmptyLines(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -51,9 +83,8 @@ func TestFixEmptyLines(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
 			result := &FixResult{Fixes: make([]Fix, 0)}
-			fixed := fixer.fixEmptyLines(tt.input, result)
+			fixed := fixLines(t, "C-L2", tt.input, result)
 
 			if len(fixed) != len(tt.expected) {
 				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
@@ -81,27 +112,76 @@ func TestFixIndentation(t *testing.T) {
 	}{
 		{
 			name:     "Replace 4 spaces with tab",
-			input:    []string{"    int x;"},
-			expected: []string{"\tint x;"},
+			input:    []string{"int main(void)", "{", "    return (0);", "}"},
+			expected: []string{"int main(void)", "{", "\treturn (0);", "}"},
 			numFixes: 1,
 		},
 		{
-			name:     "Replace 8 spaces with 2 tabs",
-			input:    []string{"        int x;"},
-			expected: []string{"\t\tint x;"},
-			numFixes: 1,
+			name:     "Nested blocks",
+			input:    []string{"void f(void)", "{", "    while (1) {", "        if (x)", "            break;", "    }", "}"},
+			expected: []string{"void f(void)", "{", "\twhile (1) {", "\t\tif (x)", "\t\t\tbreak;", "\t}", "}"},
+			numFixes: 4,
 		},
 		{
 			name:     "Keep tabs",
-			input:    []string{"\tint x;"},
-			expected: []string{"\tint x;"},
+			input:    []string{"void f(void)", "{", "\tint x;", "}"},
+			expected: []string{"void f(void)", "{", "\tint x;", "}"},
 			numFixes: 0,
 		},
 		{
-			name:     "Mixed spaces (5 spaces = 1 tab + 1 space)",
-			input:    []string{"     int x;"},
-			expected: []string{"\t int x;"},
-			numFixes: 1,
+			name:     "Mixed tabs and spaces",
+			input:    []string{"void f(void)", "{", "\t  int x;", "  \tint y;", "}"},
+			expected: []string{"void f(void)", "{", "\tint x;", "\tint y;", "}"},
+			numFixes: 2,
+		},
+		{
+			name:     "Wrong depth",
+			input:    []string{"\tint x;", "void f(void)", "{", "\t\t\tx = 1;", "}"},
+			expected: []string{"int x;", "void f(void)", "{", "\tx = 1;", "}"},
+			numFixes: 2,
+		},
+		{
+			name: "Case labels",
+			input: []string{"void f(int c)", "{", "switch (c) {", "case 1:", "g();", "break;",
+				"default:", "break;", "}", "}"},
+			expected: []string{"void f(int c)", "{", "\tswitch (c) {", "\t\tcase 1:", "\t\t\tg();", "\t\t\tbreak;",
+				"\t\tdefault:", "\t\t\tbreak;", "\t}", "}"},
+			numFixes: 7,
+		},
+		{
+			name:     "Continuation lines",
+			input:    []string{"int f(void)", "{", "return (g(1,", "2) + 3);", "}"},
+			expected: []string{"int f(void)", "{", "\treturn (g(1,", "\t\t2) + 3);", "}"},
+			numFixes: 2,
+		},
+		{
+			name: "Unbraced control bodies",
+			input: []string{"void f(void)", "{", "if (a)", "if (b)", "x();", "if (c)", "x();", "else if (d)",
+				"while (e)", "y(1,", "2);", "else", "for (;;) {", "z();", "}", "w();", "}"},
+			expected: []string{"void f(void)", "{", "\tif (a)", "\t\tif (b)", "\t\t\tx();", "\tif (c)", "\t\tx();",
+				"\telse if (d)", "\t\twhile (e)", "\t\t\ty(1,", "\t\t\t\t2);", "\telse", "\t\tfor (;;) {", "\t\t\tz();",
+				"\t\t}", "\tw();", "}"},
+			numFixes: 14,
+		},
+		{
+			name:     "Initializer list",
+			input:    []string{"static const int values[] = {", "1,", "2", "};", "enum color {", "RED,", "BLUE", "};"},
+			expected: []string{"static const int values[] = {", "\t1,", "\t2", "};", "enum color {", "\tRED,", "\tBLUE", "};"},
+			numFixes: 4,
+		},
+		{
+			name: "Preprocessor nesting",
+			input: []string{"#ifdef DEBUG", "\t#define LOG 1", "#else", "#  define LOG 0", "#endif",
+				"void f(void)", "{", "#ifdef DEBUG", "if (LOG) {", "#else", "if (!LOG) {", "#endif", "g();", "}", "}"},
+			expected: []string{"#ifdef DEBUG", "#    define LOG 1", "#else", "#    define LOG 0", "#endif",
+				"void f(void)", "{", "#ifdef DEBUG", "\tif (LOG) {", "#else", "\tif (!LOG) {", "#endif", "\t\tg();", "\t}", "}"},
+			numFixes: 6,
+		},
+		{
+			name:     "Comments",
+			input:    []string{"void f(void)", "{", "  /*", "  ** text", "  */", "   ", "}"},
+			expected: []string{"void f(void)", "{", "\t/*", "\t** text", "\t*/", "", "}"},
+			numFixes: 4,
 		},
 		{
 			name:     "No indentation",
@@ -113,9 +193,8 @@ func TestFixIndentation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
 			result := &FixResult{Fixes: make([]Fix, 0)}
-			fixed := fixer.fixIndentation(tt.input, result)
+			fixed := fixLines(t, "C-L3", tt.input, result)
 
 			if len(fixed) != len(tt.expected) {
 				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
@@ -171,13 +250,27 @@ func TestFixMultipleVariableDeclarations(t *testing.T) {
 			expected: []string{"for (int i = 0, j = 0; i < 10; i++)"},
 			numFixes: 0,
 		},
+		{
+			name: "Typedef and struct types",
+			input: []string{"typedef struct node node_t;", "void f(void)", "{",
+				"\tnode_t *head = NULL, tail;", "\tstruct node a, *b[2];", "\tunsigned long x = g(1, 2), y;", "}"},
+			expected: []string{"typedef struct node node_t;", "void f(void)", "{",
+				"\tnode_t *head = NULL;", "\tnode_t tail;", "\tstruct node a;", "\tstruct node *b[2];",
+				"\tunsigned long x = g(1, 2);", "\tunsigned long y;", "}"},
+			numFixes: 3,
+		},
+		{
+			name:     "Struct body",
+			input:    []string{"static struct point {", "\tint x;", "} origin, *last;", "struct {", "\tint x;", "} a, b;"},
+			expected: []string{"static struct point {", "\tint x;", "} origin;", "static struct point *last;", "struct {", "\tint x;", "} a, b;"},
+			numFixes: 1,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
 			result := &FixResult{Fixes: make([]Fix, 0)}
-			fixed := fixer.fixMultipleVariableDeclarations(tt.input, result)
+			fixed := fixLines(t, "C-L4", tt.input, result)
 
 			if len(fixed) != len(tt.expected) {
 				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
@@ -198,10 +291,11 @@ func TestFixMultipleVariableDeclarations(t *testing.T) {
 
 func TestFixCommentFormat(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    []string
-		expected []string
-		numFixes int
+		name        string
+		input       []string
+		expected    []string
+		numFixes    int
+		numWarnings int
 	}{
 		{
 			name:     "Convert simple comment",
@@ -233,13 +327,64 @@ func TestFixCommentFormat(t *testing.T) {
 			expected: []string{"/* Comment 1 */", "int x;", "/* Comment 2 */"},
 			numFixes: 2,
 		},
+		{
+			name:     "Slashes in a string",
+			input:    []string{`printf("http://x");`},
+			expected: []string{`printf("http://x");`},
+			numFixes: 0,
+		},
+		{
+			name:     "Slashes in a block comment",
+			input:    []string{"/* see http://x */"},
+			expected: []string{"/* see http://x */"},
+			numFixes: 0,
+		},
+		{
+			name:     "Merge consecutive comments",
+			input:    []string{"\t// first", "\t//", "\t// second", "\treturn 0;"},
+			expected: []string{"\t/*", "\t** first", "\t**", "\t** second", "\t*/", "\treturn 0;"},
+			numFixes: 1,
+		},
+		{
+			name:     "Do not merge trailing comments",
+			input:    []string{"int x; // x", "int y; // y"},
+			expected: []string{"int x; /* x */", "int y; /* y */"},
+			numFixes: 2,
+		},
+		{
+			name:     "Do not merge different indentation",
+			input:    []string{"// top", "\t// inner"},
+			expected: []string{"/* top */", "\t/* inner */"},
+			numFixes: 2,
+		},
+		{
+			name:     "Escape comment end",
+			input:    []string{"// a */ b"},
+			expected: []string{"/* a * / b */"},
+			numFixes: 1,
+		},
+		{
+			name:     "Comment after a directive",
+			input:    []string{"#define SIZE 4 // bytes", `#include "x.h" // local`},
+			expected: []string{"#define SIZE 4 /* bytes */", `#include "x.h" /* local */`},
+			numFixes: 2,
+		},
+		{
+			name:        "Keep comment continued by a backslash",
+			input:       []string{"// a \\", "b;"},
+			expected:    []string{"// a \\", "b;"},
+			numFixes:    0,
+			numWarnings: 1,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
 			result := &FixResult{Fixes: make([]Fix, 0)}
-			fixed := fixer.fixCommentFormat(tt.input, result)
+			fixed := fixLines(t, "C-C1", tt.input, result)
+			if len(result.Warnings) != tt.numWarnings {
+				t.Errorf("Expected %d warnings, got %d", tt.numWarnings, len(result.Warnings))
+			}
 
 			if len(fixed) != len(tt.expected) {
 				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
@@ -287,9 +432,8 @@ func TestFixForLoopDeclarations(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
 			result := &FixResult{Fixes: make([]Fix, 0)}
-			fixed := fixer.fixForLoopDeclarations(tt.input, result)
+			fixed := fixLines(t, "C-L5", tt.input, result)
 
 			if len(fixed) != len(tt.expected) {
 				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
@@ -310,6 +454,61 @@ func TestFixForLoopDeclarations(t *testing.T) {
 	}
 }
 
+func TestFixPointerDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+		numFixes int
+	}{
+		{
+			name:     "Asterisk attached to type",
+			input:    []string{"char* str;"},
+			expected: []string{"char *str;"},
+			numFixes: 1,
+		},
+		{
+			name:     "Spaces around double pointer",
+			input:    []string{"int main(int argc, char * * argv);"},
+			expected: []string{"int main(int argc, char **argv);"},
+			numFixes: 1,
+		},
+		{
+			name:     "Cast",
+			input:    []string{"\tp = (char*)malloc(n);"},
+			expected: []string{"\tp = (char *)malloc(n);"},
+			numFixes: 1,
+		},
+		{
+			name:     "Keep multiplication",
+			input:    []string{"int x = a*b;"},
+			expected: []string{"int x = a*b;"},
+			numFixes: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &FixResult{Fixes: make([]Fix, 0)}
+			fixed := fixLines(t, "C-V3", tt.input, result)
+
+			if len(fixed) != len(tt.expected) {
+				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
+			}
+
+			for i := range fixed {
+				if i < len(tt.expected) && fixed[i] != tt.expected[i] {
+					t.Errorf("Line %d: expected %q, got %q", i, tt.expected[i], fixed[i])
+				}
+			}
+
+			if len(result.Fixes) != tt.numFixes {
+				t.Errorf("Expected %d fixes, got %d", tt.numFixes, len(result.Fixes))
+			}
+		})
+	}
+}
+
 func TestToSnakeCase(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -348,8 +547,7 @@ func TestShouldFixFilename(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.filename, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
-			result := fixer.shouldFixFilename(tt.filename)
+			result := len(fixRename(t, tt.filename)) > 0
 			if result != tt.expected {
 				t.Errorf("Expected %v, got %v", tt.expected, result)
 			}
@@ -370,8 +568,10 @@ func TestFixFilename(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			fixer := NewFixer(nil, true)
-			result := fixer.fixFilename(tt.input)
+			result := tt.input
+			if renames := fixRename(t, tt.input); len(renames) > 0 {
+				result = filepath.Join(filepath.Dir(tt.input), renames[0])
+			}
 			if result != tt.expected {
 				t.Errorf("Expected %q, got %q", tt.expected, result)
 			}
@@ -379,6 +579,235 @@ func TestFixFilename(t *testing.T) {
 	}
 }
 
+func TestFixExplicitVoid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+		numFixes int
+	}{
+		{
+			name:     "Prototype and definition",
+			input:    []string{"int get_value();", "", "int main()", "{", "\treturn get_value();", "}"},
+			expected: []string{"int get_value(void);", "", "int main(void)", "{", "\treturn get_value();", "}"},
+			numFixes: 2,
+		},
+		{
+			name:     "Already void",
+			input:    []string{"int get_value(void);"},
+			expected: []string{"int get_value(void);"},
+			numFixes: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &FixResult{Fixes: make([]Fix, 0)}
+			fixed := fixLines(t, "C-F5", tt.input, result)
+
+			if len(fixed) != len(tt.expected) {
+				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(fixed))
+			}
+			for i := range fixed {
+				if i < len(tt.expected) && fixed[i] != tt.expected[i] {
+					t.Errorf("Line %d: expected %q, got %q", i, tt.expected[i], fixed[i])
+				}
+			}
+			if len(result.Fixes) != tt.numFixes {
+				t.Errorf("Expected %d fixes, got %d", tt.numFixes, len(result.Fixes))
+			}
+		})
+	}
+}
+
+func TestFixEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		numFixes int
+	}{
+		{"Valid UTF-8", "/* caf\u00e9 */", "/* caf\u00e9 */", 0},
+		{"Byte order mark", "\xef\xbb\xbfint x;", "int x;", 1},
+		{"Latin-1", "/* caf\xe9 */\n/* \xe0 \xe8 */", "/* caf\u00e9 */\n/* \u00e0 \u00e8 */", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &FixResult{Fixes: make([]Fix, 0)}
+			fixed := fixRule(t, "C-E3", "test.c", tt.input, result)
+
+			if fixed != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, fixed)
+			}
+			if len(result.Fixes) != tt.numFixes {
+				t.Errorf("Expected %d fixes, got %d", tt.numFixes, len(result.Fixes))
+			}
+		})
+	}
+}
+
+func TestFixMidLineTabs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+		numFixes int
+	}{
+		{
+			name:     "Indentation only",
+			input:    []string{"\tint x;"},
+			expected: []string{"\tint x;"},
+			numFixes: 0,
+		},
+		{
+			name:     "Tab to next stop",
+			input:    []string{"int\tx;", "\tchar\t*s;"},
+			expected: []string{"int x;", "\tchar    *s;"},
+			numFixes: 2,
+		},
+		{
+			name:     "Tab in string literal",
+			input:    []string{"char *s = \"a\tb\";"},
+			expected: []string{"char *s = \"a\tb\";"},
+			numFixes: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &FixResult{Fixes: make([]Fix, 0)}
+			fixed := fixLines(t, "C-E4", tt.input, result)
+
+			for i := range fixed {
+				if i < len(tt.expected) && fixed[i] != tt.expected[i] {
+					t.Errorf("Line %d: expected %q, got %q", i, tt.expected[i], fixed[i])
+				}
+			}
+			if len(result.Fixes) != tt.numFixes {
+				t.Errorf("Expected %d fixes, got %d", tt.numFixes, len(result.Fixes))
+			}
+		})
+	}
+}
+
+func TestFixLineLength(t *testing.T) {
+	long := strings.Repeat("x", 60)
+	tests := []struct {
+		name        string
+		input       []string
+		expected    []string
+		numFixes    int
+		numWarnings int
+	}{
+		{
+			name:     "Short line",
+			input:    []string{"\tfoo(a, b);"},
+			expected: []string{"\tfoo(a, b);"},
+		},
+		{
+			name:     "After comma in arguments",
+			input:    []string{"void f(void)", "{", "\tmy_put_nbr(" + long + ", base, " + long + ");", "}"},
+			expected: []string{"void f(void)", "{", "\tmy_put_nbr(" + long + ",", "\t\tbase, " + long + ");", "}"},
+			numFixes: 1,
+		},
+		{
+			name:     "Space indentation",
+			input:    []string{"void f(void)", "{", "    my_put_nbr(" + long + ", base, " + long + ");", "}"},
+			expected: []string{"void f(void)", "{", "    my_put_nbr(" + long + ",", "\t\tbase, " + long + ");", "}"},
+			numFixes: 1,
+		},
+		{
+			name:     "Before binary operator",
+			input:    []string{"void f(void)", "{", "\treturn (" + long + " + other_value);", "}"},
+			expected: []string{"void f(void)", "{", "\treturn (" + long, "\t\t+ other_value);", "}"},
+			numFixes: 1,
+		},
+		{
+			name:     "Inside string literal",
+			input:    []string{"void f(void)", "{", "\tmy_putstr(\"" + strings.Repeat("word ", 16) + "end\");", "}"},
+			expected: []string{"void f(void)", "{", "\tmy_putstr(\"" + strings.Repeat("word ", 12) + "\"", "\t\t\"" + strings.Repeat("word ", 4) + "end\");", "}"},
+			numFixes: 1,
+		},
+		{
+			name:  "Own line comment",
+			input: []string{"\t/* " + strings.Repeat("comment ", 10) + "*/"},
+			expected: []string{
+				"\t/*",
+				"\t** " + strings.Repeat("comment ", 8) + "comment",
+				"\t** comment",
+				"\t*/",
+			},
+			numFixes: 1,
+		},
+		{
+			name:     "Trailing comment",
+			input:    []string{"\treturn (0); /* " + long + " */"},
+			expected: []string{"\t/* " + long + " */", "\treturn (0);"},
+			numFixes: 1,
+		},
+		{
+			name:     "Inside multi-line comment",
+			input:    []string{"/*", "** " + strings.Repeat("text ", 17), "*/"},
+			expected: []string{"/*", "** " + strings.Repeat("text ", 14) + "text", "** text text", "*/"},
+			numFixes: 1,
+		},
+		{
+			name:        "Directive",
+			input:       []string{"#define LONG_MACRO \"" + long + "\""},
+			expected:    []string{"#define LONG_MACRO \"" + long + "\""},
+			numWarnings: 1,
+		},
+		{
+			name:        "No safe split",
+			input:       []string{"\tint " + long + long + ";"},
+			expected:    []string{"\tint " + long + long + ";"},
+			numWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &FixResult{Fixes: make([]Fix, 0)}
+			fixed := fixLines(t, "C-L1", tt.input, result)
+
+			if strings.Join(fixed, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("expected %q, got %q", tt.expected, fixed)
+			}
+			if len(result.Fixes) != tt.numFixes {
+				t.Errorf("Expected %d fixes, got %d", tt.numFixes, len(result.Fixes))
+			}
+			if len(result.Warnings) != tt.numWarnings {
+				t.Errorf("Expected %d warnings, got %d", tt.numWarnings, len(result.Warnings))
+			}
+		})
+	}
+}
+
+func TestFixFile_LineEndings(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "crlf.c")
+	os.WriteFile(testFile, []byte("int x;\r\nint y;"), 0644)
+
+	result, err := NewFixer(nil, false).FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(testFile)
+	if string(content) != "int x;\nint y;\n" {
+		t.Errorf("expected LF endings and a final newline, got %q", content)
+	}
+
+	rulesFixed := make(map[string]bool)
+	for _, fix := range result.Fixes {
+		rulesFixed[fix.Rule] = true
+	}
+	if !rulesFixed["C-E1"] || !rulesFixed["C-E2"] {
+		t.Errorf("expected C-E1 and C-E2 fixes, got %+v", result.Fixes)
+	}
+}
+
 func TestFixFile(t *testing.T) {
 	// Create a temporary test file
 	tmpDir := t.TempDir()
@@ -507,3 +936,811 @@ func TestCollectCFiles(t *testing.T) {
 		t.Errorf("Expected 1 file, got %d", len(files))
 	}
 }
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldPath  string
+		newPath  string
+		before   string
+		after    string
+		expected string
+	}{
+		{
+			name:     "Unchanged",
+			oldPath:  "a.c",
+			newPath:  "a.c",
+			before:   "int x;\n",
+			after:    "int x;\n",
+			expected: "",
+		},
+		{
+			name:    "Changed line with context",
+			oldPath: "src/a.c",
+			newPath: "src/a.c",
+			before:  "1\n2\n3\n4\nold\n6\n7\n8\n9\n",
+			after:   "1\n2\n3\n4\nnew\n6\n7\n8\n9\n",
+			expected: "diff --git a/src/a.c b/src/a.c\n--- a/src/a.c\n+++ b/src/a.c\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-old\n+new\n 6\n 7\n 8\n",
+		},
+		{
+			name:    "Distant changes in separate hunks",
+			oldPath: "a.c",
+			newPath: "a.c",
+			before:  "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			after:   "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			expected: "diff --git a/a.c b/a.c\n--- a/a.c\n+++ b/a.c\n" +
+				"@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n" +
+				"@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name:    "Added final newline",
+			oldPath: "a.c",
+			newPath: "a.c",
+			before:  "int x;",
+			after:   "int x;\n",
+			expected: "diff --git a/a.c b/a.c\n--- a/a.c\n+++ b/a.c\n" +
+				"@@ -1,1 +1,1 @@\n-int x;\n\\ No newline at end of file\n+int x;\n",
+		},
+		{
+			name:     "Rename only",
+			oldPath:  "MyFile.c",
+			newPath:  "my_file.c",
+			before:   "int x;\n",
+			after:    "int x;\n",
+			expected: "diff --git a/MyFile.c b/my_file.c\nrename from MyFile.c\nrename to my_file.c\n",
+		},
+		{
+			name:    "Lines removed at the start",
+			oldPath: "a.c",
+			newPath: "a.c",
+			before:  "\n\nint x;\n",
+			after:   "int x;\n",
+			expected: "diff --git a/a.c b/a.c\n--- a/a.c\n+++ b/a.c\n" +
+				"@@ -1,3 +1,1 @@\n-\n-\n int x;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := UnifiedDiff(tt.oldPath, tt.newPath, tt.before, tt.after)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	patch := UnifiedDiff("a.c", "a.c", "--x;\nint y;\n", "++x;\nint y;\n")
+	colored := ColorizeDiff(patch)
+
+	if !strings.Contains(colored, types.ColorRed+"---x;"+types.ColorReset) {
+		t.Errorf("Expected removed line in red, got:\n%q", colored)
+	}
+	if !strings.Contains(colored, types.ColorGreen+"+++x;"+types.ColorReset) {
+		t.Errorf("Expected added line in green, got:\n%q", colored)
+	}
+	if !strings.Contains(colored, types.ColorBold+"--- a/a.c"+types.ColorReset) {
+		t.Errorf("Expected header in bold, got:\n%q", colored)
+	}
+	if !strings.Contains(colored, "\n int y;\n") {
+		t.Errorf("Expected context line uncolored, got:\n%q", colored)
+	}
+}
+
+func TestFixResult_Patch(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "MyFile.c")
+	if err := os.WriteFile(testFile, []byte("int f()\n{\n    return 0;\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewFixer(analyzer.NewAnalyzer(1), true).FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := result.Patch()
+	for _, expected := range []string{"rename from ", "my_file.c\n", "-int f()\n", "+int f(void)\n", "-    return 0;\n", "+\treturn 0;\n"} {
+		if !strings.Contains(patch, expected) {
+			t.Errorf("Expected patch to contain %q, got:\n%s", expected, patch)
+		}
+	}
+}
+
+func TestFixFile_Verification(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.c")
+	content := "int main(void)\n{\n    return 0;\n}\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewFixer(analyzer.NewAnalyzer(1), true).FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := result.Verification
+	if v == nil {
+		t.Fatal("Expected a verification")
+	}
+	if v.Before != 1 || v.After != 0 || v.Fixed() != 1 || v.Introduced() != 0 {
+		t.Errorf("Expected 1 -> 0 violations, got %d -> %d (fixed %d, introduced %d)", v.Before, v.After, v.Fixed(), v.Introduced())
+	}
+	if !v.Converged || v.Iterations != 2 {
+		t.Errorf("Expected convergence after 2 passes, got %d (converged %v)", v.Iterations, v.Converged)
+	}
+	if len(v.Rules) != 1 || v.Rules[0].Rule != "C-L3" {
+		t.Errorf("Expected a C-L3 delta, got %+v", v.Rules)
+	}
+	if result.Refused {
+		t.Error("Expected fixes to be accepted")
+	}
+}
+
+func TestFixFile_RefusesMoreViolations(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.c")
+	content := "#define X 1 // abcdefgh\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The /* */ comment no longer fits on the directive, which cannot be split
+	a := analyzer.NewAnalyzer(1)
+	cfg := types.DefaultConfig()
+	cfg.MaxLineLength = len(content) - 1
+	a.SetConfig(cfg)
+
+	result, err := NewFixer(a, false).FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Refused {
+		t.Fatal("Expected fixes adding violations to be refused")
+	}
+	if result.ModifiedContent || result.Patch() != "" {
+		t.Error("Expected no change for refused fixes")
+	}
+	if v := result.Verification; v.Before != 0 || v.After != 1 || v.Introduced() != 1 {
+		t.Errorf("Expected 0 -> 1 violations, got %d -> %d", v.Before, v.After)
+	}
+
+	readBack, _ := os.ReadFile(testFile)
+	if string(readBack) != content {
+		t.Error("Refused fixes were written")
+	}
+}
+
+func TestCompareViolations(t *testing.T) {
+	before := []types.Violation{{Rule: "C-L3"}, {Rule: "C-L3"}, {Rule: "C-L2"}}
+	after := []types.Violation{{Rule: "C-L3"}, {Rule: "C-L6"}}
+
+	v := compareViolations(before, after)
+
+	expected := []RuleDelta{
+		{Rule: "C-L2", Before: 1, After: 0},
+		{Rule: "C-L3", Before: 2, After: 1},
+		{Rule: "C-L6", Before: 0, After: 1},
+	}
+	if len(v.Rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %+v", len(expected), v.Rules)
+	}
+	for i := range expected {
+		if v.Rules[i] != expected[i] {
+			t.Errorf("Rule %d: expected %+v, got %+v", i, expected[i], v.Rules[i])
+		}
+	}
+	if v.Fixed() != 2 || v.Introduced() != 1 || v.Before != 3 || v.After != 2 {
+		t.Errorf("Expected 3 -> 2 (fixed 2, introduced 1), got %d -> %d (fixed %d, introduced %d)", v.Before, v.After, v.Fixed(), v.Introduced())
+	}
+}
+
+func TestWriteFile_PreservesMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.c")
+	if err := os.WriteFile(testFile, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFile(testFile, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 to be kept, got %o", info.Mode().Perm())
+	}
+	readBack, _ := os.ReadFile(testFile)
+	if string(readBack) != "new\n" {
+		t.Errorf("Expected new content, got %q", readBack)
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be gone, found %d entries", len(entries))
+	}
+}
+
+func TestWriteFile_Symlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "real", "t.c")
+	if err := os.Mkdir(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "link.c")
+	if err := os.Symlink(filepath.Join("real", "t.c"), link); err != nil {
+		t.Skip("symbolic links not supported:", err)
+	}
+
+	if err := writeFile(link, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Expected the link to be kept")
+	}
+	readBack, _ := os.ReadFile(target)
+	if string(readBack) != "new\n" {
+		t.Errorf("Expected the target to be written, got %q", readBack)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be gone, found %d entries", len(entries))
+	}
+}
+
+func TestFixFile_OrigBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.c")
+	content := "int main(void)\n{\n    return 0;\n}\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixer := NewFixer(analyzer.NewAnalyzer(1), false)
+	if err := fixer.SetBackup(BackupOrig); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fixer.FixFile(testFile); err != nil {
+		t.Fatal(err)
+	}
+
+	orig, err := os.ReadFile(testFile + ".orig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(orig) != content {
+		t.Errorf("Expected the .orig file to hold the original content, got %q", orig)
+	}
+	if fixer.Session() != "" {
+		t.Error("Expected no journal session with .orig backups")
+	}
+}
+
+func TestSetBackup_Invalid(t *testing.T) {
+	if err := NewFixer(nil, false).SetBackup("zip"); err == nil {
+		t.Error("Expected error for unknown backup mode")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	tmpDir := t.TempDir()
+	saved := BackupDir
+	BackupDir = filepath.Join(tmpDir, "backups")
+	defer func() { BackupDir = saved }()
+
+	if _, err := Undo(); err == nil {
+		t.Error("Expected error without fix session")
+	}
+
+	fixedFile := filepath.Join(tmpDir, "fixed.c")
+	renamedFile := filepath.Join(tmpDir, "MyFile.c")
+	fixedContent := "int main(void)\n{\n    return 0;\n}\n"
+	renamedContent := "int f(void);\n"
+	os.WriteFile(fixedFile, []byte(fixedContent), 0600)
+	os.WriteFile(renamedFile, []byte(renamedContent), 0644)
+
+	fixer := NewFixer(analyzer.NewAnalyzer(1), false)
+	if err := fixer.SetBackup(BackupJournal); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{fixedFile, renamedFile} {
+		result, err := fixer.FixFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.NewFilename != "" {
+			if err := fixer.Rename(file, result.NewFilename); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if fixer.Session() == "" {
+		t.Fatal("Expected a journal session")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "my_file.c")); err != nil {
+		t.Fatal("Expected the file to be renamed")
+	}
+
+	restored, err := Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 2 {
+		t.Errorf("Expected 2 restored files, got %v", restored)
+	}
+
+	readBack, _ := os.ReadFile(fixedFile)
+	if string(readBack) != fixedContent {
+		t.Errorf("Expected original content back, got %q", readBack)
+	}
+	if info, _ := os.Stat(fixedFile); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %o", info.Mode().Perm())
+	}
+	readBack, _ = os.ReadFile(renamedFile)
+	if string(readBack) != renamedContent {
+		t.Errorf("Expected renamed file restored, got %q", readBack)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "my_file.c")); !os.IsNotExist(err) {
+		t.Error("Expected the renamed file to be removed")
+	}
+	if _, err := os.Stat(fixer.Session()); !os.IsNotExist(err) {
+		t.Error("Expected the undone session to be deleted")
+	}
+}
+
+func TestCollectCFiles_SkipsHiddenDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "main.c"), []byte(""), 0644)
+	backups := filepath.Join(tmpDir, ".gonana", "backups", "20240101-120000")
+	os.MkdirAll(backups, 0755)
+	os.WriteFile(filepath.Join(backups, "main.c"), []byte(""), 0644)
+
+	files, err := types.CollectCFiles(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected backups to be skipped, got %v", files)
+	}
+}
+
+func TestReplaceFileWord(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"SRC = MyFile.c", "SRC = my_file.c"},
+		{"SRC = src/MyFile.c \\", "SRC = src/my_file.c \\"},
+		{"SRC = MyFile.c MyFile.c", "SRC = my_file.c my_file.c"},
+		{"SRC = notMyFile.c", "SRC = notMyFile.c"},
+		{"SRC = MyFile.cpp", "SRC = MyFile.cpp"},
+		{"$(addprefix src/,MyFile.c)", "$(addprefix src/,my_file.c)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if result := replaceFileWord(tt.line, "MyFile.c", "my_file.c"); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// writeProject creates the files of a small project and returns its root
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFixProject_UpdatesReferences(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"src/main.c":       "#include \"MyFile.h\"\n#include \"../include/MyFile.h\"\n#include <stdio.h>\n",
+		"include/MyFile.h": "int my_f(void);\n",
+		"src/unrelated.c":  "int x;\n",
+		"Makefile":         "SRC = src/main.c \\\n\tsrc/MyFile.c\n",
+		"src/MyFile.c":     "int my_f(void)\n{\n\treturn 0;\n}\n",
+	})
+
+	results, err := NewFixer(analyzer.NewAnalyzer(1), true).FixProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]*FixResult)
+	for _, r := range results {
+		byName[r.Filename] = r
+	}
+	if len(results) != 5 {
+		t.Errorf("Expected 4 fixed files and the Makefile, got %d", len(results))
+	}
+	if byName["MyFile.c"].NewFilename == "" || byName["MyFile.h"].NewFilename == "" {
+		t.Error("Expected MyFile.c and MyFile.h to be renamed")
+	}
+
+	main := byName["main.c"].Fixed
+	expected := "#include \"my_file.h\"\n#include \"../include/my_file.h\"\n#include <stdio.h>\n"
+	if main != expected {
+		t.Errorf("Expected includes updated:\n%s\nGot:\n%s", expected, main)
+	}
+	if len(byName["main.c"].Fixes) != 2 {
+		t.Errorf("Expected 2 reference fixes, got %+v", byName["main.c"].Fixes)
+	}
+
+	makefile := byName["Makefile"]
+	if makefile == nil {
+		t.Fatal("Expected the Makefile to be updated")
+	}
+	if makefile.Fixed != "SRC = src/main.c \\\n\tsrc/my_file.c\n" {
+		t.Errorf("Expected Makefile SRC updated, got %q", makefile.Fixed)
+	}
+	if !strings.Contains(makefile.Patch(), "+\tsrc/my_file.c\n") {
+		t.Errorf("Expected the Makefile in the patch, got:\n%s", makefile.Patch())
+	}
+
+	readBack, _ := os.ReadFile(filepath.Join(root, "src", "main.c"))
+	if !strings.Contains(string(readBack), "MyFile.h") {
+		t.Error("Expected no change on disk in dry run")
+	}
+}
+
+func TestFixProject_Collision(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"MyFile.c":  "int x;\n",
+		"my_file.c": "int y;\n",
+		"main.c":    "#include \"MyFile.c\"\n",
+	})
+
+	results, err := NewFixer(analyzer.NewAnalyzer(1), false).FixProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range results {
+		if r.NewFilename != "" {
+			t.Errorf("Expected no rename, %s is renamed to %s", r.Filename, r.NewFilename)
+		}
+		if r.Filename == "MyFile.c" && (len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0].Description, "already exists")) {
+			t.Errorf("Expected a collision warning, got %+v", r.Warnings)
+		}
+		if r.Filename == "MyFile.c" && r.Verification.Fixed() != 0 {
+			t.Error("Expected the C-O1 violation to remain")
+		}
+	}
+
+	readBack, _ := os.ReadFile(filepath.Join(root, "main.c"))
+	if string(readBack) != "#include \"MyFile.c\"\n" {
+		t.Errorf("Expected include unchanged, got %q", readBack)
+	}
+}
+
+func TestToScreamingSnakeCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"maxSize", "MAX_SIZE"},
+		{"buffer_len", "BUFFER_LEN"},
+		{"ALREADY", "ALREADY"},
+		{"MaxSize2", "MAX_SIZE2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result := types.ToScreamingSnakeCase(tt.input); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFixProject_RenamesSymbols(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"sum.h": "#define maxSize 10\nint computeSum(int a, int b);\nint compute_total(void);\n",
+		"sum.c": "#include \"sum.h\"\n\nstatic int localHelp(void)\n{\n\treturn maxSize;\n}\n\n" +
+			"int computeSum(int a, int b)\n{\n\tint (*fp)(int, int) = computeSum;\n\n" +
+			"\tprintf(\"computeSum\\n\");\n\treturn a + b + computeSumX + localHelp();\n}\n\n" +
+			"int computeTotal(void)\n{\n\treturn 0;\n}\n",
+		"other.c": "#include \"sum.h\"\n\nstatic int localHelp(void);\n\nint run(void)\n{\n\treturn computeSum(1, maxSize);\n}\n",
+	})
+
+	results, err := NewFixer(analyzer.NewAnalyzer(1), true).FixProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*FixResult)
+	for _, r := range results {
+		byName[r.Filename] = r
+	}
+
+	expected := map[string]string{
+		"sum.h": "#define MAX_SIZE 10\nint compute_sum(int a, int b);\nint compute_total(void);\n",
+		"sum.c": "#include \"sum.h\"\n\nstatic int local_help(void)\n{\n\treturn MAX_SIZE;\n}\n\n" +
+			"int compute_sum(int a, int b)\n{\n\tint (*fp)(int, int) = compute_sum;\n\n" +
+			"\tprintf(\"computeSum\\n\");\n\treturn a + b + computeSumX + local_help();\n}\n\n" +
+			"int computeTotal(void)\n{\n\treturn 0;\n}\n",
+		"other.c": "#include \"sum.h\"\n\nstatic int localHelp(void);\n\nint run(void)\n{\n\treturn compute_sum(1, MAX_SIZE);\n}\n",
+	}
+	for name, content := range expected {
+		if byName[name] == nil {
+			t.Errorf("Expected a result for %s", name)
+			continue
+		}
+		if byName[name].Fixed != content {
+			t.Errorf("%s: expected:\n%s\nGot:\n%s", name, content, byName[name].Fixed)
+		}
+	}
+
+	warnings := byName["sum.c"].Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Description, "computeTotal") {
+		t.Errorf("Expected a collision warning for computeTotal, got %+v", warnings)
+	}
+}
+
+func TestFixProject_RenamesOnlyReferences(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"ops.c": "struct ops {\n\tint (*doThing)(int);\n\tint count;\n};\n\n" +
+			"int doThing(int x)\n{\n\treturn (x);\n}\n\n" +
+			"int run(struct ops *o, int count)\n{\n\tint (*f)(int) = doThing;\n\n" +
+			"\treturn (o->doThing(count) + f(1));\n}\n\n" +
+			"int other(void)\n{\n\tint doThing = 2;\n\n\treturn (doThing);\n}\n",
+	})
+
+	results, err := NewFixer(nil, true).FixProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "struct ops {\n\tint (*doThing)(int);\n\tint count;\n};\n\n" +
+		"int do_thing(int x)\n{\n\treturn (x);\n}\n\n" +
+		"int run(struct ops *o, int count)\n{\n\tint (*f)(int) = do_thing;\n\n" +
+		"\treturn (o->doThing(count) + f(1));\n}\n\n" +
+		"int other(void)\n{\n\tint doThing = 2;\n\n\treturn (doThing);\n}\n"
+	if results[0].Fixed != expected {
+		t.Errorf("Expected only the references to the function renamed, got:\n%s", results[0].Fixed)
+	}
+
+	renamed := 0
+	for _, fix := range results[0].Fixes {
+		if fix.Rule != "C-F1" {
+			continue
+		}
+		renamed++
+		if fix.Line != 6 || !strings.Contains(fix.Description, "2 occurrence(s)") {
+			t.Errorf("Expected the rename reported at the definition with 2 occurrences, got %+v", fix)
+		}
+	}
+	if renamed != 1 {
+		t.Errorf("Expected one C-F1 rename, got %d", renamed)
+	}
+}
+
+func TestFixProject_RefusedByRenames(t *testing.T) {
+	// Renaming computeTotal makes a line of other.c too long: other.c is
+	// refused, and the function keeps its name in both files
+	call := "\treturn (computeTotal() + " + strings.Repeat("1", 49) + ");"
+	files := map[string]string{
+		"total.c": "int computeTotal(void)\n{\n    return (0);\n}\n",
+		"other.c": "int computeTotal(void);\n\nint run(void)\n{\n" + call + "\n}\n",
+	}
+	root := writeProject(t, files)
+
+	results, err := NewFixer(analyzer.NewAnalyzer(1), false).FixProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*FixResult)
+	for _, r := range results {
+		byName[r.Filename] = r
+	}
+
+	if !byName["other.c"].Refused {
+		t.Error("Expected other.c to be refused after the rename")
+	}
+	expected := map[string]string{
+		"total.c": "int computeTotal(void)\n{\n\treturn (0);\n}\n",
+		"other.c": files["other.c"],
+	}
+	for name, content := range expected {
+		written, _ := os.ReadFile(filepath.Join(root, name))
+		if string(written) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, written)
+		}
+	}
+
+	warnings := byName["total.c"].Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Description, "other.c is left unchanged") {
+		t.Errorf("Expected a warning on the dropped rename, got %+v", warnings)
+	}
+	if v := byName["total.c"].Verification; v == nil || v.After != 1 {
+		t.Errorf("Expected the verification without the rename, got %+v", v)
+	}
+}
+
+func TestFixFile_Confirm(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.c")
+	content := "int f()\n{\n    return (0);\n}\n\n\nint g()\n{\n    return (1);\n}"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decline the indentation fixes, accept everything else
+	asked := make(map[string]int)
+	f := NewFixer(nil, false)
+	f.SetConfirm(func(p Proposal) bool {
+		asked[p.Rule]++
+		if p.Filename != "test.c" {
+			t.Errorf("Expected proposals for test.c, got %q", p.Filename)
+		}
+		if p.Rule == "C-L3" && !strings.Contains(p.Diff, "-    return") {
+			t.Errorf("Expected the diff of the change, got %q", p.Diff)
+		}
+		return p.Rule != "C-L3"
+	})
+
+	result, err := f.FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "int f(void)\n{\n    return (0);\n}\n\nint g(void)\n{\n    return (1);\n}\n"
+	readBack, _ := os.ReadFile(testFile)
+	if string(readBack) != expected {
+		t.Errorf("Expected only the accepted fixes, got %q", readBack)
+	}
+	if asked["C-L3"] != 2 {
+		t.Errorf("Expected each declined fix to be asked once, asked %d times", asked["C-L3"])
+	}
+	if len(result.Declined) != 2 {
+		t.Errorf("Expected 2 declined fixes, got %d", len(result.Declined))
+	}
+	for _, fix := range result.Fixes {
+		if fix.Rule == "C-L3" {
+			t.Error("Declined fix recorded as applied")
+		}
+	}
+}
+
+func TestFixFile_ConfirmRename(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "myFile.c")
+	if err := os.WriteFile(testFile, []byte("int x;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFixer(nil, false)
+	f.SetConfirm(func(p Proposal) bool { return false })
+	result, err := f.FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NewFilename != "" || len(result.Fixes) != 0 {
+		t.Errorf("Expected the declined rename to be dropped, got %q", result.NewFilename)
+	}
+}
+
+func TestApplyEdits_Overlaps(t *testing.T) {
+	edits := []types.Edit{
+		{Start: 4, End: 4, Text: "void"},
+		{Start: 0, End: 3, Text: "int"},
+	}
+	if fixed := applyEdits("abc(x)", edits); fixed != "int(voidx)" {
+		t.Errorf("Expected the edits applied by offset, got %q", fixed)
+	}
+
+	tests := []struct {
+		name     string
+		a, b     types.Edit
+		expected bool
+	}{
+		{"Disjoint", types.Edit{Start: 0, End: 2}, types.Edit{Start: 2, End: 4}, false},
+		{"Overlapping", types.Edit{Start: 0, End: 3}, types.Edit{Start: 2, End: 4}, true},
+		{"Insertion at the end", types.Edit{Start: 0, End: 2}, types.Edit{Start: 2, End: 2}, true},
+		{"Insertion elsewhere", types.Edit{Start: 0, End: 2}, types.Edit{Start: 3, End: 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlaps(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFixContent_OverlappingFixes(t *testing.T) {
+	// The indentation and the comment fixes both rewrite the first line:
+	// the comment fix waits for the next run
+	f := NewFixer(nil, true)
+	result := &FixResult{}
+	fixed := f.fixContent("test.c", "int f(void)\n{\n    g(); // count\n}\n", result)
+	if fixed != "int f(void)\n{\n\tg(); // count\n}\n" {
+		t.Errorf("Expected only the fix of higher precedence, got %q", fixed)
+	}
+	if len(result.Fixes) != 1 || result.Fixes[0].Rule != "C-L3" {
+		t.Errorf("Expected a single C-L3 fix, got %+v", result.Fixes)
+	}
+
+	fixed = f.fixContent("test.c", fixed, &FixResult{})
+	if fixed != "int f(void)\n{\n\tg(); /* count */\n}\n" {
+		t.Errorf("Expected the comment fix on the next run, got %q", fixed)
+	}
+}
+
+func TestFixFile_PassesNumberedFromFinalContent(t *testing.T) {
+	// The declaration split adds a line, and leaves the new line with the
+	// indentation of the original: the second pass reindents it
+	content := "int main(void)\n{\n  int a, b;\n\n    a = 1;\n  b = 2;\n  return (a + b);\n}\n"
+	testFile := filepath.Join(t.TempDir(), "test.c")
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewFixer(nil, true).FixFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.iterations < 2 {
+		t.Fatalf("Expected several passes, got %d", result.iterations)
+	}
+
+	var got []string
+	for _, fix := range result.Fixes {
+		got = append(got, fmt.Sprintf("%s:%d", fix.Rule, fix.Line))
+	}
+	expected := []string{"C-L3:3", "C-L3:6", "C-L3:7", "C-L3:8", "C-L4:3"}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected fixes %v, got %v", expected, got)
+	}
+	lines := types.SplitLines(result.Fixed)
+	for _, fix := range result.Fixes {
+		if fix.Rule == "C-L3" && !strings.HasPrefix(lines[fix.Line-1], "\t") {
+			t.Errorf("Line %d of the fixed content is not reindented: %q", fix.Line, lines[fix.Line-1])
+		}
+	}
+}
+
+func TestSetOnly(t *testing.T) {
+	f := NewFixer(nil, true)
+	if err := f.SetOnly([]string{"C-XX"}); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+	if err := f.SetOnly([]string{"C-F3"}); err == nil {
+		t.Error("Expected an error for a rule without fix")
+	}
+	if err := f.SetOnly([]string{"C-L3", " C-C1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := &FixResult{}
+	fixed := f.fixContent("test.c", "int f() // main\n{\n    g();\n}\n", result)
+	expected := "int f() /* main */\n{\n\tg();\n}\n"
+	if fixed != expected {
+		t.Errorf("Expected only the C-L3 and C-C1 fixes, got %q", fixed)
+	}
+	if f.selected("C-F1") {
+		t.Error("Expected the renames of C-F1 to be left out")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package fixer
+
+import "os"
+
+// copyOwner does nothing where files have no unix owner
+func copyOwner(filename string, info os.FileInfo) {}
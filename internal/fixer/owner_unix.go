@@ -0,0 +1,15 @@
+//go:build unix
+
+package fixer
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner gives filename the owner and group of info, when allowed
+func copyOwner(filename string, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = os.Chown(filename, int(st.Uid), int(st.Gid))
+	}
+}
@@ -0,0 +1,425 @@
+package fixer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/types"
+)
+
+// FixProject fixes the C files of path. C-O1 renames are checked for
+// collisions and applied project-wide: the #include directives and the
+// Makefiles of the project are updated to the new names. The project is
+// path itself for a directory, or the directory of a file. Files are
+// checked again after the renames: a file they make refused is left
+// unchanged, and so are the names it uses. Files are written unless in dry
+// run mode; the renames are left to Rename. Errors on single files do not
+// stop the others and are returned joined.
+func (f *Fixer) FixProject(path string) ([]*FixResult, error) {
+	files, err := types.CollectCFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*FixResult
+	var errs []error
+	for _, file := range files {
+		result, err := f.prepareFix(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", file, err))
+			continue
+		}
+		results = append(results, result)
+	}
+
+	checkRenames(results)
+	for _, result := range results {
+		f.verify(result)
+	}
+
+	root := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		root = filepath.Dir(path)
+	}
+	if f.confirm != nil {
+		// The renames may be applied again: ask about each one once
+		confirm := f.confirm
+		defer func() { f.confirm = confirm }()
+		f.confirm = confirmOnce(confirm)
+	}
+
+	// Apply the renames, then check the files again. When the renames
+	// make a file refused, they are started over without it.
+	var projectErr error
+	for {
+		keepIncludedNames(results)
+		symbols := f.symbolRenames(results)
+		renames := renamedBases(results)
+		if len(symbols) == 0 && len(renames) == 0 {
+			break
+		}
+
+		states := make([]fixState, len(results))
+		for i, result := range results {
+			states[i] = saveState(result)
+		}
+		project, err := projectFiles(root, results)
+		if err != nil && projectErr == nil {
+			projectErr = err
+		}
+		f.renameSymbols(project, symbols)
+		updateReferences(project, renames)
+
+		refused := make(map[*FixResult]bool)
+		for _, result := range results {
+			if !result.Refused {
+				f.verify(result)
+				refused[result] = result.Refused
+			}
+		}
+		if !anyTrue(refused) {
+			// Keep the other files the renames changed
+			fixed := make(map[*FixResult]bool)
+			for _, result := range results {
+				fixed[result] = true
+			}
+			for _, result := range project {
+				if !fixed[result] && len(result.Fixes) > 0 {
+					results = append(results, result)
+				}
+			}
+			break
+		}
+
+		for i, result := range results {
+			states[i].restore(result)
+			if refused[result] {
+				refuse(result)
+			} else if !result.Refused {
+				f.compare(result)
+			}
+		}
+	}
+	if projectErr != nil {
+		errs = append(errs, projectErr)
+	}
+
+	for _, result := range results {
+		if err := f.write(result); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", result.Path, err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// checkRenames drops the C-O1 renames whose new name is already taken, by
+// an existing file or by another rename
+func checkRenames(results []*FixResult) {
+	targets := make(map[string]bool)
+
+	for _, result := range results {
+		if result.NewFilename == "" {
+			continue
+		}
+		target, _ := filepath.Abs(result.NewFilename)
+
+		reason := ""
+		if targets[target] {
+			reason = "another file is renamed to it"
+		} else if exists(result.NewFilename) && !sameFile(result.Path, result.NewFilename) {
+			reason = "the file already exists"
+		}
+		if reason != "" {
+			result.Warnings = append(result.Warnings, Fix{
+				Rule:        "C-O1",
+				Description: fmt.Sprintf("Not renamed to %s: %s", filepath.Base(result.NewFilename), reason),
+			})
+			result.Fixes = withoutRename(result.Fixes)
+			result.NewFilename = ""
+			continue
+		}
+
+		targets[target] = true
+	}
+}
+
+// fixState is the part of a result the project renames change
+type fixState struct {
+	fixed       string
+	fixes       []Fix
+	warnings    []Fix
+	declined    []Fix
+	newFilename string
+}
+
+// saveState returns the current state of a result
+func saveState(result *FixResult) fixState {
+	return fixState{
+		fixed:       result.Fixed,
+		fixes:       append([]Fix(nil), result.Fixes...),
+		warnings:    append([]Fix(nil), result.Warnings...),
+		declined:    append([]Fix(nil), result.Declined...),
+		newFilename: result.NewFilename,
+	}
+}
+
+// restore puts a result back in a saved state
+func (s fixState) restore(result *FixResult) {
+	result.Fixed = s.fixed
+	result.Fixes = append([]Fix(nil), s.fixes...)
+	result.Warnings = append([]Fix(nil), s.warnings...)
+	result.Declined = append([]Fix(nil), s.declined...)
+	result.NewFilename = s.newFilename
+}
+
+// anyTrue reports whether one of the values of a set is true
+func anyTrue(set map[*FixResult]bool) bool {
+	for _, v := range set {
+		if v {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmOnce wraps a confirmation function so that a proposal already
+// answered gets the same answer without asking again
+func confirmOnce(confirm func(Proposal) bool) func(Proposal) bool {
+	answers := make(map[string]bool)
+	return func(p Proposal) bool {
+		key := p.Rule + "\x00" + p.Filename + "\x00" + p.Description
+		answer, ok := answers[key]
+		if !ok {
+			answer = confirm(p)
+			answers[key] = answer
+		}
+		return answer
+	}
+}
+
+// keepIncludedNames drops the C-O1 renames of the files included by a
+// refused file, which is left unchanged and must keep naming them
+func keepIncludedNames(results []*FixResult) {
+	for _, refused := range results {
+		if !refused.Refused {
+			continue
+		}
+		for _, line := range types.SplitLines(refused.Fixed) {
+			m := includeLine.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			base := m[2][strings.LastIndex(m[2], "/")+1:]
+			for _, result := range results {
+				if result.NewFilename == "" || filepath.Base(result.Path) != base {
+					continue
+				}
+				result.Warnings = append(result.Warnings, Fix{
+					Rule:        "C-O1",
+					Description: fmt.Sprintf("Not renamed to %s: %s is left unchanged", filepath.Base(result.NewFilename), refused.Filename),
+				})
+				result.Fixes = withoutRename(result.Fixes)
+				result.NewFilename = ""
+			}
+		}
+	}
+}
+
+// renamedBases maps the base names of the renamed files to their new one
+func renamedBases(results []*FixResult) map[string]string {
+	renames := make(map[string]string)
+	for _, result := range results {
+		if result.NewFilename != "" {
+			renames[filepath.Base(result.Path)] = filepath.Base(result.NewFilename)
+		}
+	}
+	return renames
+}
+
+// exists reports whether a file exists
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// sameFile reports whether two paths name the same file, as the old and
+// new names of a case-only rename do on case-insensitive file systems
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// withoutRename removes the rename fix from a list of fixes
+func withoutRename(fixes []Fix) []Fix {
+	kept := fixes[:0]
+	for _, fix := range fixes {
+		if fix.Rename == "" {
+			kept = append(kept, fix)
+		}
+	}
+	return kept
+}
+
+// referenceFiles returns the C files and Makefiles of the project that
+// may name other files
+func referenceFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(p)
+		if ext == ".c" || ext == ".h" || types.FileKind(p) == types.KindMakefile {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// projectFiles returns the C files and Makefiles of the project, as the
+// fix results of the fixed files or as unchanged results for the others
+func projectFiles(root string, results []*FixResult) ([]*FixResult, error) {
+	files, err := referenceFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*FixResult)
+	for _, result := range results {
+		abs, _ := filepath.Abs(result.Path)
+		byPath[abs] = result
+	}
+
+	var project []*FixResult
+	for _, file := range files {
+		abs, _ := filepath.Abs(file)
+		if result := byPath[abs]; result != nil {
+			project = append(project, result)
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return project, err
+		}
+		project = append(project, &FixResult{
+			Filename: filepath.Base(file),
+			Path:     file,
+			Original: string(content),
+			Fixed:    string(content),
+		})
+	}
+	return project, nil
+}
+
+// updateReferences rewrites the #include directives and the Makefile
+// words naming the renamed files of the project
+func updateReferences(project []*FixResult, renames map[string]string) {
+	for _, result := range project {
+		if types.FileKind(result.Path) == types.KindMakefile {
+			result.Fixed = updateMakefileReferences(result.Fixed, renames, result)
+		} else {
+			result.Fixed = updateIncludes(result.Fixed, renames, result)
+		}
+	}
+}
+
+// includeLine matches an #include directive and splits its path out
+var includeLine = regexp.MustCompile(`^(\s*#\s*include\s*["<])([^">]+)([">].*)$`)
+
+// updateIncludes rewrites the #include directives naming a renamed file
+func updateIncludes(content string, renames map[string]string, result *FixResult) string {
+	lines := splitKeepingNewlines(content)
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		m := includeLine.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		include := m[2]
+		base := include[strings.LastIndex(include, "/")+1:]
+		newBase, ok := renames[base]
+		if !ok {
+			continue
+		}
+		include = include[:len(include)-len(base)] + newBase
+		lines[i] = m[1] + include + m[3] + line[len(text):]
+
+		result.Fixes = append(result.Fixes, Fix{
+			Rule:        "C-O1",
+			Description: fmt.Sprintf("Updated #include of %s to %s", base, newBase),
+			Line:        i + 1,
+		})
+	}
+	return strings.Join(lines, "")
+}
+
+// updateMakefileReferences rewrites the words of a Makefile naming a
+// renamed file, alone or at the end of a path
+func updateMakefileReferences(content string, renames map[string]string, result *FixResult) string {
+	names := make([]string, 0, len(renames))
+	for name := range renames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := splitKeepingNewlines(content)
+	for i, line := range lines {
+		for _, name := range names {
+			fixed := replaceFileWord(line, name, renames[name])
+			if fixed == line {
+				continue
+			}
+			lines[i] = fixed
+			line = fixed
+			result.Fixes = append(result.Fixes, Fix{
+				Rule:        "C-O1",
+				Description: fmt.Sprintf("Updated reference to %s to %s", name, renames[name]),
+				Line:        i + 1,
+			})
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// replaceFileWord replaces the occurrences of name in line that are not
+// part of a longer file name
+func replaceFileWord(line, name, replacement string) string {
+	var b strings.Builder
+	pos := 0
+	for {
+		i := strings.Index(line[pos:], name)
+		if i < 0 {
+			break
+		}
+		start, end := pos+i, pos+i+len(name)
+		b.WriteString(line[pos:start])
+		if (start == 0 || !isFileNameChar(line[start-1])) && (end == len(line) || !isFileNameChar(line[end])) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteString(name)
+		}
+		pos = end
+	}
+	b.WriteString(line[pos:])
+	return b.String()
+}
+
+// isFileNameChar reports whether c may be part of a file name in a
+// Makefile word
+func isFileNameChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '+' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,433 @@
+package fixer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// symbolRename renames a function (C-F1) or a macro (C-F2) to its
+// expected case
+type symbolRename struct {
+	Rule string
+	Kind string // "function" or "macro"
+	Old  string
+	New  string
+	File string // absolute path of the only file concerned, "" for the whole project
+}
+
+// symbolRenames returns the renames of the badly named functions and
+// macros defined by the fixed files, for the selected rules. Static
+// functions and the macros of .c files are only renamed in their own file.
+func (f *Fixer) symbolRenames(results []*FixResult) []symbolRename {
+	var renames []symbolRename
+	seen := make(map[symbolRename]bool)
+	add := func(r symbolRename) {
+		if !f.selected(r.Rule) || r.New == r.Old || parser.IsKeyword(r.New) || seen[r] {
+			return
+		}
+		seen[r] = true
+		renames = append(renames, r)
+	}
+
+	for _, result := range results {
+		if types.FileKind(result.Path) != types.KindC || result.Refused {
+			continue
+		}
+		abs, _ := filepath.Abs(result.Path)
+		unit := parser.Parse(types.SplitLines(result.Fixed))
+
+		for _, fn := range unit.Functions {
+			if types.IsSnakeCase(fn.Name) || fn.Name == "main" {
+				continue
+			}
+			r := symbolRename{Rule: "C-F1", Kind: "function", Old: fn.Name, New: types.ToSnakeCase(fn.Name)}
+			if fn.Static {
+				r.File = abs
+			}
+			add(r)
+		}
+		for _, m := range unit.Macros() {
+			if types.IsScreamingSnakeCase(m.Name) {
+				continue
+			}
+			r := symbolRename{Rule: "C-F2", Kind: "macro", Old: m.Name, New: types.ToScreamingSnakeCase(m.Name)}
+			if filepath.Ext(result.Path) == ".c" {
+				r.File = abs
+			}
+			add(r)
+		}
+	}
+	return renames
+}
+
+// identifier is an identifier token of a file, possibly inside a
+// preprocessor directive
+type identifier struct {
+	Text   string
+	Offset int // byte offset in the content
+	Line   int
+	Ref    bool // may name a file-scope function or macro
+}
+
+// identifiers returns the identifiers of C source code, including those
+// of the directives other than #include. Strings, characters and
+// comments are not identifiers. Members, and names a parameter or a local
+// declaration shadows, are not references to functions or macros.
+func identifiers(content string) []identifier {
+	unit := parser.ParseSource(content)
+	hidden := hiddenNames(unit)
+
+	var idents []identifier
+	for i, t := range unit.Tokens {
+		switch t.Kind {
+		case parser.Ident:
+			idents = append(idents, identifier{t.Text, t.Offset, t.Line, !hidden[i]})
+		case parser.Directive:
+			d := parser.ParseDirective(t.Text)
+			if d.Name == "include" {
+				continue
+			}
+			params := make(map[string]bool)
+			if d.Name == "define" {
+				for _, p := range d.Macro().Params {
+					params[p] = true
+				}
+			}
+			subs := parser.Lex(t.Text[1:])
+			for j, sub := range subs {
+				if sub.Kind != parser.Ident {
+					continue
+				}
+				member := j > 0 && (subs[j-1].Is(".") || subs[j-1].Is("->"))
+				idents = append(idents, identifier{sub.Text, t.Offset + 1 + sub.Offset, t.Line + sub.Line - 1, !member && !params[sub.Text]})
+			}
+		}
+	}
+	return idents
+}
+
+// hiddenNames returns the indexes of the identifier tokens that cannot
+// name a file-scope function or macro: members reached through '.' or
+// '->', members declared in a struct or union body, parameters, and the
+// names of local declarations up to the end of their block
+func hiddenNames(unit *parser.Unit) map[int]bool {
+	toks := unit.Tokens
+	hidden := make(map[int]bool)
+
+	for i, t := range toks {
+		if t.Kind == parser.Ident {
+			if p := unit.Prev(i - 1); p >= 0 && (toks[p].Is(".") || toks[p].Is("->")) {
+				hidden[i] = true
+			}
+		}
+		if !t.Is("{") {
+			continue
+		}
+		tag := unit.Prev(i - 1)
+		if tag >= 0 && toks[tag].Kind == parser.Ident {
+			tag = unit.Prev(tag - 1)
+		}
+		if tag < 0 || !(toks[tag].Is("struct") || toks[tag].Is("union")) {
+			continue
+		}
+		end := unit.Match(i)
+		if end < 0 {
+			end = len(toks)
+		}
+		for j := i + 1; j < end; j++ {
+			if toks[j].Kind == parser.Ident && isMemberName(unit, j) {
+				hidden[j] = true
+			}
+		}
+	}
+
+	for _, decl := range unit.Decls {
+		for _, d := range decl.Declarators {
+			for _, p := range d.Params {
+				if p.Name != "" {
+					hidden[p.NameTok] = true
+				}
+			}
+		}
+	}
+	for _, fn := range unit.Functions {
+		for _, p := range fn.Params {
+			if p.Name != "" {
+				hideName(unit, hidden, p.Name, p.NameTok, fn.Close)
+			}
+		}
+		fn.Walk(func(s *parser.Statement) {
+			if s.Decl == nil {
+				return
+			}
+			for _, d := range s.Decl.Declarators {
+				hideName(unit, hidden, d.Name, d.NameTok, blockEnd(unit, d.NameTok, fn.Open))
+			}
+		})
+	}
+	return hidden
+}
+
+// isMemberName reports whether the identifier at i is the name declared
+// by a member declaration, such as 'count' in 'int count;' or 'run' in
+// 'int (*run)(void);'
+func isMemberName(unit *parser.Unit, i int) bool {
+	next := unit.Next(i + 1)
+	if next >= len(unit.Tokens) {
+		return false
+	}
+	n := unit.Tokens[next]
+	if n.Is(";") || n.Is(",") || n.Is("[") || n.Is(":") {
+		return true
+	}
+	prev := unit.Prev(i - 1)
+	return n.Is(")") && prev >= 0 && unit.Tokens[prev].Is("*")
+}
+
+// hideName hides the identifiers named name from the token from to the
+// token to
+func hideName(unit *parser.Unit, hidden map[int]bool, name string, from, to int) {
+	if from < 0 {
+		return
+	}
+	for k := from; k <= to && k < len(unit.Tokens); k++ {
+		if t := unit.Tokens[k]; t.Kind == parser.Ident && t.Text == name {
+			hidden[k] = true
+		}
+	}
+}
+
+// blockEnd returns the closing brace of the innermost block containing
+// the token at i, the function body opening at open at most
+func blockEnd(unit *parser.Unit, i, open int) int {
+	depth := 0
+	for k := i - 1; k > open; k-- {
+		switch {
+		case unit.Tokens[k].Is("}"):
+			depth++
+		case unit.Tokens[k].Is("{") && depth > 0:
+			depth--
+		case unit.Tokens[k].Is("{"):
+			return unit.Match(k)
+		}
+	}
+	return unit.Match(open)
+}
+
+// renameSymbols applies the function and macro renames to every C file
+// of the project but the refused ones. A rename to a name the project
+// already uses, or of a symbol a refused file uses, is dropped with a
+// warning on the files defining the symbol, and a rename declined when
+// asked for confirmation is recorded on them.
+func (f *Fixer) renameSymbols(project []*FixResult, renames []symbolRename) {
+	if len(renames) == 0 {
+		return
+	}
+
+	idents := make(map[*FixResult][]identifier)
+	used := make(map[string]bool)
+	for _, result := range project {
+		if types.FileKind(result.Path) != types.KindC {
+			continue
+		}
+		idents[result] = identifiers(result.Fixed)
+		for _, id := range idents[result] {
+			used[id.Text] = true
+		}
+	}
+
+	// Drop the renames colliding with an existing name or with each other
+	var kept []symbolRename
+	targets := make(map[string]string)
+	for _, r := range renames {
+		reason := ""
+		if refused := usedByRefused(project, idents, r); refused != nil {
+			reason = fmt.Sprintf("%s is left unchanged", refused.Filename)
+		} else if used[r.New] {
+			reason = "the name is already used"
+		} else if old, ok := targets[r.New]; ok && old != r.Old {
+			reason = fmt.Sprintf("'%s' is renamed to it too", old)
+		}
+		if reason == "" {
+			targets[r.New] = r.Old
+			proposal := Proposal{Fix: Fix{Rule: r.Rule, Description: fmt.Sprintf("Rename %s '%s' to '%s' in the project", r.Kind, r.Old, r.New)}}
+			if r.File != "" {
+				proposal.Filename = filepath.Base(r.File)
+				proposal.Description = fmt.Sprintf("Rename %s '%s' to '%s'", r.Kind, r.Old, r.New)
+			}
+			if f.confirm == nil || f.confirm(proposal) {
+				kept = append(kept, r)
+				continue
+			}
+			for _, result := range definingFiles(project, r) {
+				result.Declined = append(result.Declined, proposal.Fix)
+			}
+			continue
+		}
+		for _, result := range definingFiles(project, r) {
+			result.Warnings = append(result.Warnings, Fix{
+				Rule:        r.Rule,
+				Description: fmt.Sprintf("Not renamed %s '%s' to '%s': %s", r.Kind, r.Old, r.New, reason),
+			})
+		}
+	}
+
+	for _, result := range project {
+		if ids, ok := idents[result]; ok && !result.Refused {
+			result.Fixed = applySymbolRenames(result, ids, kept)
+		}
+	}
+}
+
+// usedByRefused returns a refused file naming the symbol of a rename, or
+// nil
+func usedByRefused(project []*FixResult, idents map[*FixResult][]identifier, r symbolRename) *FixResult {
+	for _, result := range project {
+		if !result.Refused {
+			continue
+		}
+		if abs, _ := filepath.Abs(result.Path); r.File != "" && r.File != abs {
+			continue
+		}
+		for _, id := range idents[result] {
+			if id.Ref && id.Text == r.Old {
+				return result
+			}
+		}
+	}
+	return nil
+}
+
+// definingFiles returns the files of the project concerned by a rename
+// that define its symbol
+func definingFiles(project []*FixResult, r symbolRename) []*FixResult {
+	var files []*FixResult
+	for _, result := range project {
+		abs, _ := filepath.Abs(result.Path)
+		if (r.File == "" || r.File == abs) && defines(result, r) {
+			files = append(files, result)
+		}
+	}
+	return files
+}
+
+// defines reports whether the fixed content of a file defines the symbol
+// of a rename
+func defines(result *FixResult, r symbolRename) bool {
+	if types.FileKind(result.Path) != types.KindC {
+		return false
+	}
+	unit := parser.Parse(types.SplitLines(result.Fixed))
+	if r.Kind == "macro" {
+		for _, m := range unit.Macros() {
+			if m.Name == r.Old {
+				return true
+			}
+		}
+		return false
+	}
+	for _, fn := range unit.Functions {
+		if fn.Name == r.Old {
+			return true
+		}
+	}
+	return false
+}
+
+// definitionLines maps the functions and macros defined by C source code
+// to the line of their definition
+func definitionLines(content string) map[string]int {
+	unit := parser.ParseSource(content)
+	lines := make(map[string]int)
+	for _, fn := range unit.Functions {
+		lines[fn.Name] = fn.Line
+	}
+	for _, m := range unit.Macros() {
+		if _, ok := lines[m.Name]; !ok {
+			lines[m.Name] = m.Line
+		}
+	}
+	return lines
+}
+
+// applySymbolRenames replaces the references of a file to the symbols of
+// the renames, recording one fix per renamed symbol at its definition, or
+// at its first reference in the files not defining it
+func applySymbolRenames(result *FixResult, ids []identifier, renames []symbolRename) string {
+	abs, _ := filepath.Abs(result.Path)
+	global := make(map[string]symbolRename)
+	local := make(map[string]symbolRename)
+	for _, r := range renames {
+		switch r.File {
+		case "":
+			global[r.Old] = r
+		case abs:
+			local[r.Old] = r
+		}
+	}
+
+	type occurrences struct {
+		rename symbolRename
+		count  int
+		line   int
+	}
+	found := make(map[string]*occurrences)
+
+	content := result.Fixed
+	defined := definitionLines(content)
+	var b strings.Builder
+	pos := 0
+	for _, id := range ids {
+		if !id.Ref {
+			continue
+		}
+		r, ok := local[id.Text]
+		if !ok {
+			r, ok = global[id.Text]
+		}
+		if !ok {
+			continue
+		}
+		b.WriteString(content[pos:id.Offset])
+		b.WriteString(r.New)
+		pos = id.Offset + len(id.Text)
+
+		if found[r.Old] == nil {
+			line, ok := defined[r.Old]
+			if !ok {
+				line = id.Line
+			}
+			found[r.Old] = &occurrences{rename: r, line: line}
+		}
+		found[r.Old].count++
+	}
+	if pos == 0 {
+		return content
+	}
+	b.WriteString(content[pos:])
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if found[names[i]].line != found[names[j]].line {
+			return found[names[i]].line < found[names[j]].line
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		o := found[name]
+		result.Fixes = append(result.Fixes, Fix{
+			Rule:        o.rename.Rule,
+			Description: fmt.Sprintf("Renamed %s '%s' to '%s' (%d occurrence(s))", o.rename.Kind, o.rename.Old, o.rename.New, o.count),
+			Line:        o.line,
+		})
+	}
+	return b.String()
+}
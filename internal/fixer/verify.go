@@ -0,0 +1,78 @@
+package fixer
+
+import (
+	"sort"
+
+	"epicstyle/internal/types"
+)
+
+// RuleDelta counts the violations of a rule before and after the fixes
+type RuleDelta struct {
+	Rule   string
+	Before int
+	After  int
+}
+
+// Fixed returns the number of violations of the rule the fixes removed
+func (d RuleDelta) Fixed() int {
+	return max(d.Before-d.After, 0)
+}
+
+// Introduced returns the number of violations of the rule the fixes added
+func (d RuleDelta) Introduced() int {
+	return max(d.After-d.Before, 0)
+}
+
+// Verification compares the analysis of a file before and after its fixes
+type Verification struct {
+	Iterations int  // fix passes run
+	Converged  bool // the last pass left the content unchanged
+	Before     int  // violations before the fixes
+	After      int  // violations remaining after the fixes
+	Rules      []RuleDelta
+}
+
+// Fixed returns the number of violations the fixes removed
+func (v *Verification) Fixed() int {
+	total := 0
+	for _, d := range v.Rules {
+		total += d.Fixed()
+	}
+	return total
+}
+
+// Introduced returns the number of violations the fixes added
+func (v *Verification) Introduced() int {
+	total := 0
+	for _, d := range v.Rules {
+		total += d.Introduced()
+	}
+	return total
+}
+
+// compareViolations counts the violations of each rule before and after
+// the fixes, rules sorted by code
+func compareViolations(before, after []types.Violation) *Verification {
+	deltas := make(map[string]*RuleDelta)
+	delta := func(rule string) *RuleDelta {
+		if deltas[rule] == nil {
+			deltas[rule] = &RuleDelta{Rule: rule}
+		}
+		return deltas[rule]
+	}
+	for _, v := range before {
+		delta(v.Rule).Before++
+	}
+	for _, v := range after {
+		delta(v.Rule).After++
+	}
+
+	verification := &Verification{Before: len(before), After: len(after)}
+	for _, d := range deltas {
+		verification.Rules = append(verification.Rules, *d)
+	}
+	sort.Slice(verification.Rules, func(i, j int) bool {
+		return verification.Rules[i].Rule < verification.Rules[j].Rule
+	})
+	return verification
+}
@@ -0,0 +1,46 @@
+package fixer
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFile replaces the content of filename atomically: the data is
+// written to a temporary file of the same directory, which is renamed
+// over the original. A symbolic link is written through: the file it
+// points to is replaced and the link kept. An existing file keeps its mode
+// and ownership, a new one is created with perm.
+func writeFile(filename string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+	info, err := os.Stat(filename)
+	if err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if info != nil {
+		copyOwner(tmp.Name(), info)
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,233 @@
+package parser
+
+import "strings"
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+const (
+	Ident TokenKind = iota
+	Keyword
+	Number
+	String
+	Char
+	Punct
+	Comment
+	Directive
+)
+
+// Token is a single lexical element of a C source file
+type Token struct {
+	Kind    TokenKind
+	Text    string
+	Line    int // 1-based line of the first character
+	Col     int // 1-based byte column of the first character
+	EndLine int // line of the last character
+	Offset  int // byte offset in the source
+}
+
+// Is reports whether the token is the given punctuator or keyword
+func (t Token) Is(text string) bool {
+	return (t.Kind == Punct || t.Kind == Keyword) && t.Text == text
+}
+
+var keywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extern": true, "float": true, "for": true, "goto": true,
+	"if": true, "inline": true, "int": true, "long": true, "register": true,
+	"restrict": true, "return": true, "short": true, "signed": true, "sizeof": true,
+	"static": true, "struct": true, "switch": true, "typedef": true, "union": true,
+	"unsigned": true, "void": true, "volatile": true, "while": true, "_Bool": true,
+}
+
+// punctuators are sorted longest first so the lexer takes the longest match
+var punctuators = []string{
+	"...", "<<=", ">>=",
+	"->", "++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"*=", "/=", "%=", "+=", "-=", "&=", "^=", "|=", "##",
+}
+
+// IsKeyword reports whether s is a reserved C keyword
+func IsKeyword(s string) bool {
+	return keywords[s]
+}
+
+// Lex splits C source code into tokens, including comments and
+// preprocessor directives
+func Lex(src string) []Token {
+	var tokens []Token
+	line, col := 1, 1
+	lineStart := true
+	i := 0
+
+	advance := func(n int) {
+		for k := 0; k < n && i < len(src); k++ {
+			if src[i] == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+			i++
+		}
+	}
+
+	for i < len(src) {
+		c := src[i]
+
+		if c == '\n' {
+			lineStart = true
+			advance(1)
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' {
+			advance(1)
+			continue
+		}
+
+		tok := Token{Line: line, Col: col, Offset: i}
+		start := i
+
+		switch {
+		case c == '#' && lineStart:
+			tok.Kind = Directive
+			advance(directiveLength(src[i:]))
+		case strings.HasPrefix(src[i:], "/*"):
+			tok.Kind = Comment
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				advance(len(src) - i)
+			} else {
+				advance(end + 4)
+			}
+		case strings.HasPrefix(src[i:], "//"):
+			tok.Kind = Comment
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			advance(end)
+		case c == '"' || c == '\'':
+			tok.Kind = String
+			if c == '\'' {
+				tok.Kind = Char
+			}
+			advance(quotedLength(src[i:], c))
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			tok.Kind = Number
+			advance(numberLength(src[i:]))
+		case isIdentStart(c):
+			n := 1
+			for i+n < len(src) && isIdentChar(src[i+n]) {
+				n++
+			}
+			tok.Kind = Ident
+			if keywords[src[i:i+n]] {
+				tok.Kind = Keyword
+			}
+			advance(n)
+		default:
+			tok.Kind = Punct
+			n := 1
+			for _, p := range punctuators {
+				if strings.HasPrefix(src[i:], p) {
+					n = len(p)
+					break
+				}
+			}
+			advance(n)
+		}
+
+		tok.Text = src[start:i]
+		tok.EndLine = line
+		tokens = append(tokens, tok)
+		lineStart = false
+	}
+
+	return tokens
+}
+
+// directiveLength returns the length of a preprocessor directive, following
+// backslash continuations and block comments spanning several lines
+func directiveLength(s string) int {
+	i := 0
+	for i < len(s) {
+		switch {
+		case s[i] == '\n':
+			return i
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			i += 2
+		case s[i] == '\\' && i+2 < len(s) && s[i+1] == '\r' && s[i+2] == '\n':
+			i += 3
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 4
+		case strings.HasPrefix(s[i:], "//"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return len(s)
+			}
+			i += end
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+// quotedLength returns the length of a string or character literal
+func quotedLength(s string, quote byte) int {
+	i := 1
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			i += 2
+			continue
+		case quote:
+			return i + 1
+		case '\n':
+			return i
+		}
+		i++
+	}
+	return len(s)
+}
+
+// numberLength returns the length of a numeric literal, including suffixes
+// and signed exponents
+func numberLength(s string) int {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if (c == '+' || c == '-') && i > 0 {
+			prev := s[i-1] | 0x20
+			hex := len(s) > 1 && (s[1]|0x20) == 'x'
+			if (prev == 'e' && !hex) || prev == 'p' {
+				i++
+				continue
+			}
+			break
+		}
+		if !isIdentChar(c) && c != '.' {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
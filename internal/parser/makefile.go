@@ -0,0 +1,189 @@
+package parser
+
+import "strings"
+
+// MakeRule is a rule of a Makefile: targets, prerequisites and recipe
+type MakeRule struct {
+	Targets []string
+	Prereqs []string // order-only prerequisites included
+	Recipe  []string // recipe lines, without the leading tab
+	Line    int
+}
+
+// MakeLine is a logical line of a Makefile, continuation lines joined
+type MakeLine struct {
+	Text   string // comment removed
+	Line   int    // first physical line
+	Recipe bool   // starts with a tab
+}
+
+// Makefile is the parsed structure of a Makefile
+type Makefile struct {
+	Lines     []MakeLine
+	Rules     []*MakeRule
+	Variables map[string]int // variable name to the line of its first assignment
+	Phony     map[string]bool
+}
+
+// ParseMakefile parses the lines of a Makefile. Conditionals and include
+// directives are kept as lines but do not affect the structure: every
+// branch of a conditional is read.
+func ParseMakefile(lines []string) *Makefile {
+	mk := &Makefile{Variables: make(map[string]int), Phony: make(map[string]bool)}
+	mk.Lines = joinMakeLines(lines)
+
+	var current *MakeRule
+	for _, l := range mk.Lines {
+		if l.Recipe && current != nil {
+			if text := strings.TrimSpace(l.Text); text != "" {
+				current.Recipe = append(current.Recipe, text)
+			}
+			continue
+		}
+
+		text := strings.TrimSpace(l.Text)
+		if text == "" {
+			continue
+		}
+		if isMakeDirective(text) {
+			continue
+		}
+
+		if name, ok := makeAssignment(text); ok {
+			if _, seen := mk.Variables[name]; !seen {
+				mk.Variables[name] = l.Line
+			}
+			current = nil
+			continue
+		}
+
+		colon := makeRuleColon(text)
+		if colon < 0 {
+			current = nil
+			continue
+		}
+		rule := &MakeRule{Targets: strings.Fields(text[:colon]), Line: l.Line}
+		rest := strings.TrimLeft(text[colon+1:], ":")
+		if semi := strings.IndexByte(rest, ';'); semi >= 0 {
+			if recipe := strings.TrimSpace(rest[semi+1:]); recipe != "" {
+				rule.Recipe = append(rule.Recipe, recipe)
+			}
+			rest = rest[:semi]
+		}
+		for _, p := range strings.Fields(rest) {
+			if p != "|" {
+				rule.Prereqs = append(rule.Prereqs, p)
+			}
+		}
+
+		for _, t := range rule.Targets {
+			if t == ".PHONY" {
+				for _, p := range rule.Prereqs {
+					mk.Phony[p] = true
+				}
+			}
+		}
+		mk.Rules = append(mk.Rules, rule)
+		current = rule
+	}
+	return mk
+}
+
+// Target returns the first rule building target, or nil
+func (m *Makefile) Target(target string) *MakeRule {
+	for _, r := range m.Rules {
+		for _, t := range r.Targets {
+			if t == target {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// joinMakeLines joins continuation lines and removes comments
+func joinMakeLines(lines []string) []MakeLine {
+	var joined []MakeLine
+	for i := 0; i < len(lines); i++ {
+		l := MakeLine{Line: i + 1, Recipe: strings.HasPrefix(lines[i], "\t")}
+		text := lines[i]
+		for strings.HasSuffix(text, "\\") && i+1 < len(lines) {
+			i++
+			text = text[:len(text)-1] + " " + strings.TrimSpace(lines[i])
+		}
+		l.Text = stripMakeComment(text)
+		joined = append(joined, l)
+	}
+	return joined
+}
+
+// stripMakeComment removes a '#' comment, escaped "\#" excepted
+func stripMakeComment(text string) string {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && (i == 0 || text[i-1] != '\\') {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// isMakeDirective reports whether a line is a conditional or an include
+func isMakeDirective(text string) bool {
+	word := text
+	if i := strings.IndexAny(text, " \t("); i >= 0 {
+		word = text[:i]
+	}
+	switch word {
+	case "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif",
+		"include", "-include", "sinclude", "export", "unexport", "vpath", "define", "endef":
+		return true
+	}
+	return false
+}
+
+// makeAssignment returns the variable assigned by a line, if it is one
+func makeAssignment(text string) (string, bool) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ':':
+			if depth > 0 {
+				continue
+			}
+			if strings.HasPrefix(text[i:], ":=") || strings.HasPrefix(text[i:], "::=") {
+				return strings.TrimSpace(text[:i]), true
+			}
+			return "", false
+		case '=':
+			if depth > 0 {
+				continue
+			}
+			name := strings.TrimRight(text[:i], "+?! \t")
+			return strings.TrimSpace(name), true
+		}
+	}
+	return "", false
+}
+
+// makeRuleColon returns the index of the colon separating the targets of
+// a rule from its prerequisites, or -1
+func makeRuleColon(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ':':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,808 @@
+package parser
+
+import "strings"
+
+// Unit is the parsed form of a C source file. It is deliberately shallow:
+// expressions are kept as token ranges, only declarations and statements
+// are structured.
+type Unit struct {
+	Tokens    []Token
+	Functions []*Function
+	Decls     []*Declaration // file-scope declarations, including prototypes
+	Typedefs  map[string]bool
+}
+
+// Function is a function definition
+type Function struct {
+	Name       string
+	NameTok    int
+	Static     bool
+	Start      int // first token of the definition
+	ParamOpen  int
+	ParamClose int
+	Open       int // opening brace of the body
+	Close      int // closing brace of the body
+	Line       int
+	EndLine    int
+	Params     []*Param
+	Body       []*Statement
+}
+
+// Param is a function parameter
+type Param struct {
+	Type     string // base type, e.g. "char" or "struct node"
+	Name     string // empty for unnamed parameters
+	NameTok  int
+	Pointers int
+	Array    bool
+	Const    bool
+	Start    int
+	End      int
+}
+
+// Declaration is a declaration statement, at file scope or in a block
+type Declaration struct {
+	Start       int // first token
+	End         int // terminating ';'
+	Line        int
+	EndLine     int
+	Typedef     bool
+	Static      bool
+	Extern      bool
+	Const       bool
+	Type        string // base type, e.g. "unsigned int" or "struct node"
+	TypeEnd     int    // last token of the declaration specifiers
+	TagKind     string // "struct", "union" or "enum" when a tag is named
+	Tag         string
+	TagTok      int
+	Enumerators []int // name tokens of the constants of an enum body
+	Declarators []*Declarator
+}
+
+// Declarator is a single name introduced by a declaration
+type Declarator struct {
+	Name     string
+	NameTok  int
+	Pointers int
+	Function bool
+	Array    bool
+	Init     int      // first token of the initializer, -1 when there is none
+	Params   []*Param // parameters of a function declarator
+	Start    int
+	End      int
+
+	// ParamOpen and ParamClose are the parentheses of the parameter list
+	// of a function declarator
+	ParamOpen  int
+	ParamClose int
+}
+
+// StmtKind identifies the kind of a statement
+type StmtKind int
+
+const (
+	StmtExpr StmtKind = iota
+	StmtDecl
+	StmtBlock
+	StmtEmpty
+	StmtIf
+	StmtFor
+	StmtWhile
+	StmtDo
+	StmtSwitch
+	StmtCase
+	StmtLabel
+	StmtJump
+)
+
+// Statement is a statement inside a function body. Control statements
+// keep their controlled statements in Body (and Else for if statements),
+// blocks keep their contents in Body.
+type Statement struct {
+	Kind    StmtKind
+	Start   int
+	End     int
+	Line    int
+	EndLine int
+	HeadEnd int // last token of the statement head: ')' of the condition, 'else' or 'do'
+	ElseTok int // 'else' keyword of an if statement, -1 when absent
+	Body    []*Statement
+	Else    *Statement
+	Decl    *Declaration
+}
+
+// typeKeywords are the keywords that may start a declaration
+var typeKeywords = map[string]bool{
+	"auto": true, "char": true, "const": true, "double": true, "enum": true,
+	"extern": true, "float": true, "inline": true, "int": true, "long": true,
+	"register": true, "restrict": true, "short": true, "signed": true,
+	"static": true, "struct": true, "typedef": true, "union": true,
+	"unsigned": true, "void": true, "volatile": true, "_Bool": true,
+}
+
+// knownTypes are common library typedefs that do not follow the _t suffix
+var knownTypes = map[string]bool{
+	"FILE": true, "DIR": true, "va_list": true, "bool": true, "jmp_buf": true,
+}
+
+type parser struct {
+	toks     []Token
+	typedefs map[string]bool
+}
+
+// Parse tokenizes and parses the given source lines
+func Parse(lines []string) *Unit {
+	return ParseSource(strings.Join(lines, "\n"))
+}
+
+// ParseSource tokenizes and parses C source code
+func ParseSource(src string) *Unit {
+	p := &parser{toks: Lex(src), typedefs: make(map[string]bool)}
+	unit := &Unit{Tokens: p.toks, Typedefs: p.typedefs}
+	p.parseTopLevel(unit)
+	return unit
+}
+
+// IsTypeName reports whether the identifier names a type, either declared
+// by a typedef in this unit or following the usual library conventions
+func (u *Unit) IsTypeName(name string) bool {
+	return u.Typedefs[name] || knownTypes[name] || strings.HasSuffix(name, "_t")
+}
+
+// Next returns the index of the first code token (not a comment or a
+// directive) at or after i, or len(Tokens) when there is none
+func (u *Unit) Next(i int) int {
+	p := parser{toks: u.Tokens}
+	return p.skip(i)
+}
+
+// Prev returns the index of the last code token at or before i, or -1
+func (u *Unit) Prev(i int) int {
+	for i >= 0 && i < len(u.Tokens) && !isCode(u.Tokens[i]) {
+		i--
+	}
+	return i
+}
+
+// Match returns the index of the bracket closing the one at i, or -1
+func (u *Unit) Match(i int) int {
+	p := parser{toks: u.Tokens}
+	return p.match(i, len(u.Tokens))
+}
+
+// Walk calls fn for every statement of the function, depth first
+func (f *Function) Walk(fn func(*Statement)) {
+	WalkStatements(f.Body, fn)
+}
+
+// WalkStatements calls fn for every statement of the list, depth first
+func WalkStatements(stmts []*Statement, fn func(*Statement)) {
+	for _, s := range stmts {
+		fn(s)
+		WalkStatements(s.Body, fn)
+		if s.Else != nil {
+			WalkStatements([]*Statement{s.Else}, fn)
+		}
+	}
+}
+
+// AllDeclarations returns file-scope declarations followed by the
+// declarations of every function body, in source order
+func (u *Unit) AllDeclarations() []*Declaration {
+	decls := append([]*Declaration(nil), u.Decls...)
+	for _, fn := range u.Functions {
+		fn.Walk(func(s *Statement) {
+			if s.Decl != nil {
+				decls = append(decls, s.Decl)
+			}
+		})
+	}
+	return decls
+}
+
+func isCode(t Token) bool {
+	return t.Kind != Comment && t.Kind != Directive
+}
+
+// skip returns the first code token index at or after i
+func (p *parser) skip(i int) int {
+	for i < len(p.toks) && !isCode(p.toks[i]) {
+		i++
+	}
+	return i
+}
+
+// match finds the bracket closing the one at i, without going past limit
+func (p *parser) match(i, limit int) int {
+	if i < 0 || i >= len(p.toks) {
+		return -1
+	}
+	open := p.toks[i].Text
+	var close string
+	switch open {
+	case "(":
+		close = ")"
+	case "[":
+		close = "]"
+	case "{":
+		close = "}"
+	default:
+		return -1
+	}
+	depth := 0
+	for j := i; j < limit && j < len(p.toks); j = p.skip(j + 1) {
+		t := p.toks[j]
+		if t.Kind != Punct {
+			continue
+		}
+		if t.Text == open {
+			depth++
+		} else if t.Text == close {
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// parseTopLevel splits the file into function definitions and declarations
+func (p *parser) parseTopLevel(unit *Unit) {
+	n := len(p.toks)
+	i := p.skip(0)
+	for i < n {
+		start := i
+		depth := 0
+		next := n
+		for j := i; j < n; j = p.skip(j + 1) {
+			t := p.toks[j]
+			if t.Kind != Punct {
+				continue
+			}
+			switch t.Text {
+			case "(", "[":
+				depth++
+				continue
+			case ")", "]":
+				depth--
+				continue
+			case "{":
+				if depth != 0 {
+					continue
+				}
+				if fn := p.parseFunction(start, j); fn != nil {
+					unit.Functions = append(unit.Functions, fn)
+					next = fn.Close + 1
+					break
+				}
+				close := p.match(j, n)
+				if close < 0 {
+					j = n - 1
+				} else {
+					j = close
+				}
+				continue
+			case ";":
+				if depth != 0 {
+					continue
+				}
+				if decl := p.parseDeclaration(start, j); decl != nil {
+					unit.Decls = append(unit.Decls, decl)
+				}
+				next = j + 1
+			default:
+				continue
+			}
+			break
+		}
+		i = p.skip(next)
+	}
+}
+
+// parseFunction parses a function definition whose body opens at brace,
+// returning nil if the tokens before the brace are not a function header
+func (p *parser) parseFunction(start, brace int) *Function {
+	closeParen := p.prevCode(brace - 1)
+	if closeParen < start || !p.toks[closeParen].Is(")") {
+		return nil
+	}
+	openParen := p.matchBack(closeParen, start)
+	if openParen < 0 {
+		return nil
+	}
+	nameTok := p.prevCode(openParen - 1)
+	if nameTok < start || p.toks[nameTok].Kind != Ident {
+		return nil
+	}
+	static := false
+	for j := start; j < nameTok; j = p.skip(j + 1) {
+		t := p.toks[j]
+		if t.Is("=") {
+			return nil
+		}
+		if t.Is("static") {
+			static = true
+		}
+	}
+	close := p.match(brace, len(p.toks))
+	if close < 0 {
+		close = len(p.toks) - 1
+	}
+	return &Function{
+		Params:     p.parseParams(openParen, closeParen),
+		Name:       p.toks[nameTok].Text,
+		NameTok:    nameTok,
+		Static:     static,
+		Start:      start,
+		ParamOpen:  openParen,
+		ParamClose: closeParen,
+		Open:       brace,
+		Close:      close,
+		Line:       p.toks[start].Line,
+		EndLine:    p.toks[close].EndLine,
+		Body:       p.parseBlock(brace+1, close),
+	}
+}
+
+// parseParams parses the parameter list between two parentheses. Both
+// "()" and "(void)" give an empty list.
+func (p *parser) parseParams(open, close int) []*Param {
+	var params []*Param
+	start := p.skip(open + 1)
+	if start >= close || (p.toks[start].Is("void") && p.skip(start+1) == close) {
+		return params
+	}
+	depth := 0
+	for j := start; j <= close; j = p.skip(j + 1) {
+		t := p.toks[j]
+		switch {
+		case t.Is("("), t.Is("["), t.Is("{"):
+			depth++
+		case t.Is(")") && j != close, t.Is("]"), t.Is("}"):
+			depth--
+		case (t.Is(",") && depth == 0) || j == close:
+			params = append(params, p.parseParam(start, j))
+			start = p.skip(j + 1)
+		}
+	}
+	return params
+}
+
+// parseParam parses the parameter spanning start to end (exclusive)
+func (p *parser) parseParam(start, end int) *Param {
+	param := &Param{NameTok: -1, Start: start, End: p.prevCode(end - 1)}
+	if decl := p.parseDeclaration(start, end); decl != nil && len(decl.Declarators) <= 1 {
+		param.Type = decl.Type
+		param.Const = decl.Const
+		if len(decl.Declarators) == 1 {
+			d := decl.Declarators[0]
+			param.Name, param.NameTok = d.Name, d.NameTok
+			param.Pointers, param.Array = d.Pointers, d.Array || d.Function
+		}
+		return param
+	}
+
+	// Unnamed parameter such as "char *" or "..."
+	var parts []string
+	for j := start; j < end; j = p.skip(j + 1) {
+		switch t := p.toks[j]; {
+		case t.Is("*"):
+			param.Pointers++
+		case t.Is("const"):
+			param.Const = true
+		default:
+			parts = append(parts, t.Text)
+		}
+	}
+	param.Type = strings.Join(parts, " ")
+	return param
+}
+
+// enumerators returns the name tokens of the constants of an enum body
+func (p *parser) enumerators(open, close int) []int {
+	var names []int
+	expectName := true
+	depth := 0
+	for j := p.skip(open + 1); j < close; j = p.skip(j + 1) {
+		t := p.toks[j]
+		switch {
+		case t.Is("("), t.Is("["), t.Is("{"):
+			depth++
+		case t.Is(")"), t.Is("]"), t.Is("}"):
+			depth--
+		case t.Is(",") && depth == 0:
+			expectName = true
+		case expectName && t.Kind == Ident:
+			names = append(names, j)
+			expectName = false
+		}
+	}
+	return names
+}
+
+// prevCode returns the last code token index at or before i
+func (p *parser) prevCode(i int) int {
+	for i >= 0 && !isCode(p.toks[i]) {
+		i--
+	}
+	return i
+}
+
+// matchBack finds the '(' opening the ')' at i, not going before limit
+func (p *parser) matchBack(i, limit int) int {
+	depth := 0
+	for j := i; j >= limit; j-- {
+		t := p.toks[j]
+		if t.Kind != Punct {
+			continue
+		}
+		if t.Text == ")" {
+			depth++
+		} else if t.Text == "(" {
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// parseBlock parses the statements between from (inclusive) and to (exclusive)
+func (p *parser) parseBlock(from, to int) []*Statement {
+	var stmts []*Statement
+	i := p.skip(from)
+	for i < to {
+		stmt, next := p.parseStatement(i, to)
+		stmts = append(stmts, stmt)
+		if next <= i {
+			next = i + 1
+		}
+		i = p.skip(next)
+	}
+	return stmts
+}
+
+// parseStatement parses one statement starting at i, returning it with the
+// index following it
+func (p *parser) parseStatement(i, to int) (*Statement, int) {
+	t := p.toks[i]
+	stmt := &Statement{Kind: StmtExpr, Start: i, HeadEnd: -1, ElseTok: -1}
+
+	switch {
+	case t.Is("{"):
+		close := p.match(i, to)
+		if close < 0 {
+			close = to - 1
+		}
+		stmt.Kind = StmtBlock
+		stmt.Body = p.parseBlock(i+1, close)
+		return p.finish(stmt, close), close + 1
+	case t.Is(";"):
+		stmt.Kind = StmtEmpty
+		return p.finish(stmt, i), i + 1
+	case t.Is("if"), t.Is("while"), t.Is("for"), t.Is("switch"):
+		stmt.Kind = map[string]StmtKind{"if": StmtIf, "while": StmtWhile, "for": StmtFor, "switch": StmtSwitch}[t.Text]
+		open := p.skip(i + 1)
+		if open >= to || !p.toks[open].Is("(") {
+			break
+		}
+		stmt.HeadEnd = p.match(open, to)
+		if stmt.HeadEnd < 0 {
+			break
+		}
+		next := p.skip(stmt.HeadEnd + 1)
+		if next >= to {
+			return p.finish(stmt, stmt.HeadEnd), stmt.HeadEnd + 1
+		}
+		body, after := p.parseStatement(next, to)
+		stmt.Body = []*Statement{body}
+		end := body.End
+		if stmt.Kind == StmtIf {
+			if e := p.skip(after); e < to && p.toks[e].Is("else") {
+				stmt.ElseTok = e
+				if b := p.skip(e + 1); b < to {
+					stmt.Else, after = p.parseStatement(b, to)
+					end = stmt.Else.End
+				} else {
+					end, after = e, e+1
+				}
+			}
+		}
+		return p.finish(stmt, end), after
+	case t.Is("do"):
+		stmt.Kind = StmtDo
+		stmt.HeadEnd = i
+		next := p.skip(i + 1)
+		if next >= to {
+			return p.finish(stmt, i), i + 1
+		}
+		body, after := p.parseStatement(next, to)
+		stmt.Body = []*Statement{body}
+		end := p.scanTo(after, to, ";")
+		return p.finish(stmt, end), end + 1
+	case t.Is("case"), t.Is("default"):
+		stmt.Kind = StmtCase
+		end := p.scanTo(i, to, ":")
+		return p.finish(stmt, end), end + 1
+	case t.Is("return"), t.Is("break"), t.Is("continue"), t.Is("goto"):
+		stmt.Kind = StmtJump
+	case t.Kind == Ident:
+		if n := p.skip(i + 1); n < to && p.toks[n].Is(":") {
+			stmt.Kind = StmtLabel
+			return p.finish(stmt, n), n + 1
+		}
+	}
+
+	end := p.scanTo(i, to, ";")
+	if stmt.Kind == StmtExpr && p.isDeclStart(i, end) {
+		if decl := p.parseDeclaration(i, end); decl != nil {
+			stmt.Kind = StmtDecl
+			stmt.Decl = decl
+		}
+	}
+	return p.finish(stmt, end), end + 1
+}
+
+// finish records the end of a statement
+func (p *parser) finish(stmt *Statement, end int) *Statement {
+	if end < stmt.Start {
+		end = stmt.Start
+	}
+	stmt.End = end
+	stmt.Line = p.toks[stmt.Start].Line
+	stmt.EndLine = p.toks[end].EndLine
+	return stmt
+}
+
+// scanTo returns the index of the first 'text' punctuator at bracket depth
+// zero, or the last code token before 'to' when there is none
+func (p *parser) scanTo(i, to int, text string) int {
+	depth := 0
+	ternary := 0
+	last := i
+	for j := p.skip(i); j < to; j = p.skip(j + 1) {
+		last = j
+		tok := p.toks[j]
+		if tok.Kind != Punct {
+			continue
+		}
+		switch tok.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case "?":
+			ternary++
+		case text:
+			if depth != 0 {
+				continue
+			}
+			if text == ":" && ternary > 0 {
+				ternary--
+				continue
+			}
+			return j
+		}
+	}
+	return last
+}
+
+// isDeclStart reports whether the statement starting at i is a declaration
+func (p *parser) isDeclStart(i, end int) bool {
+	t := p.toks[i]
+	if t.Kind == Keyword {
+		return typeKeywords[t.Text]
+	}
+	if t.Kind != Ident {
+		return false
+	}
+	if p.typedefs[t.Text] || knownTypes[t.Text] || strings.HasSuffix(t.Text, "_t") {
+		next := p.skip(i + 1)
+		return next <= end && (p.toks[next].Kind == Ident || p.toks[next].Is("*") || p.toks[next].Kind == Keyword)
+	}
+	return p.looksLikeTypeName(i, end)
+}
+
+// looksLikeTypeName guesses whether an unknown identifier is used as a type
+// name: "name ident" or "name *ident" followed by a declarator terminator
+func (p *parser) looksLikeTypeName(i, end int) bool {
+	j := p.skip(i + 1)
+	stars := 0
+	for j <= end && j < len(p.toks) && (p.toks[j].Is("*") || p.toks[j].Is("const")) {
+		if p.toks[j].Is("*") {
+			stars++
+		}
+		j = p.skip(j + 1)
+	}
+	if j > end || j >= len(p.toks) || p.toks[j].Kind != Ident {
+		return false
+	}
+	if stars == 0 {
+		return true
+	}
+	after := p.skip(j + 1)
+	if after > end || after >= len(p.toks) {
+		return false
+	}
+	a := p.toks[after]
+	return a.Is(";") || a.Is(",") || a.Is("=") || a.Is("[") || a.Is(")")
+}
+
+// parseDeclaration parses tokens start..end (end being the ';') as a
+// declaration, returning nil when they do not form one
+func (p *parser) parseDeclaration(start, end int) *Declaration {
+	decl := &Declaration{Start: start, End: end, TypeEnd: -1, TagTok: -1}
+	var typeParts []string
+	sawType := false
+	i := p.skip(start)
+
+	for i < end {
+		t := p.toks[i]
+		switch {
+		case t.Is("typedef"):
+			decl.Typedef = true
+		case t.Is("static"):
+			decl.Static = true
+		case t.Is("extern"):
+			decl.Extern = true
+		case t.Is("const"):
+			decl.Const = true
+		case t.Is("struct"), t.Is("union"), t.Is("enum"):
+			part := t.Text
+			j := p.skip(i + 1)
+			if j < end && p.toks[j].Kind == Ident {
+				part += " " + p.toks[j].Text
+				decl.TagKind, decl.Tag, decl.TagTok = t.Text, p.toks[j].Text, j
+				i = j
+				j = p.skip(j + 1)
+			}
+			if j < end && p.toks[j].Is("{") {
+				close := p.match(j, end)
+				if close < 0 {
+					return nil
+				}
+				if t.Text == "enum" {
+					decl.Enumerators = p.enumerators(j, close)
+				}
+				i = close
+			}
+			typeParts = append(typeParts, part)
+			sawType = true
+		case t.Kind == Keyword && typeKeywords[t.Text]:
+			if t.Text != "inline" && t.Text != "volatile" && t.Text != "register" &&
+				t.Text != "auto" && t.Text != "restrict" {
+				typeParts = append(typeParts, t.Text)
+				sawType = true
+			}
+		case t.Kind == Ident && !sawType:
+			typeParts = append(typeParts, t.Text)
+			sawType = true
+		default:
+			goto declarators
+		}
+		decl.TypeEnd = i
+		i = p.skip(i + 1)
+	}
+
+declarators:
+	if !sawType || decl.TypeEnd < 0 {
+		return nil
+	}
+	decl.Type = strings.Join(typeParts, " ")
+
+	for i < end {
+		d := p.parseDeclarator(i, end)
+		if d == nil {
+			return nil
+		}
+		decl.Declarators = append(decl.Declarators, d)
+		i = p.skip(d.End + 1)
+		if i < end && p.toks[i].Is(",") {
+			i = p.skip(i + 1)
+			continue
+		}
+		if i < end {
+			return nil
+		}
+	}
+
+	if len(decl.Declarators) == 0 && !strings.Contains(decl.Type, " ") {
+		// "name;" alone is an expression statement, not a declaration
+		if !p.typedefs[decl.Type] && !typeKeywords[p.toks[p.skip(start)].Text] {
+			return nil
+		}
+	}
+	if decl.Typedef {
+		for _, d := range decl.Declarators {
+			p.typedefs[d.Name] = true
+		}
+	}
+
+	decl.Line = p.toks[p.skip(start)].Line
+	last := end
+	if last >= len(p.toks) {
+		last = len(p.toks) - 1
+	}
+	decl.EndLine = p.toks[last].EndLine
+	return decl
+}
+
+// parseDeclarator parses one declarator starting at i, stopping before the
+// ',' that separates it from the next one or at end
+func (p *parser) parseDeclarator(i, end int) *Declarator {
+	d := &Declarator{Start: i, NameTok: -1, Init: -1}
+
+	for i < end && (p.toks[i].Is("*") || p.toks[i].Is("const") || p.toks[i].Is("restrict") || p.toks[i].Is("volatile")) {
+		if p.toks[i].Is("*") {
+			d.Pointers++
+		}
+		i = p.skip(i + 1)
+	}
+	if i >= end {
+		return nil
+	}
+
+	switch {
+	case p.toks[i].Kind == Ident:
+		d.Name = p.toks[i].Text
+		d.NameTok = i
+	case p.toks[i].Is("("):
+		// function pointer or parenthesized declarator: (*name)(...)
+		close := p.match(i, end)
+		if close < 0 {
+			return nil
+		}
+		for j := i + 1; j < close; j++ {
+			if p.toks[j].Is("*") {
+				d.Pointers++
+			} else if p.toks[j].Kind == Ident && d.NameTok < 0 {
+				d.Name = p.toks[j].Text
+				d.NameTok = j
+			}
+		}
+		if d.NameTok < 0 {
+			return nil
+		}
+		i = close
+	default:
+		return nil
+	}
+	d.End = i
+	i = p.skip(i + 1)
+
+	for i < end {
+		t := p.toks[i]
+		switch {
+		case t.Is("["):
+			close := p.match(i, end)
+			if close < 0 {
+				return nil
+			}
+			d.Array = true
+			d.End, i = close, p.skip(close+1)
+		case t.Is("("):
+			close := p.match(i, end)
+			if close < 0 {
+				return nil
+			}
+			d.Function = true
+			d.Params = p.parseParams(i, close)
+			d.ParamOpen, d.ParamClose = i, close
+			d.End, i = close, p.skip(close+1)
+		case t.Is("="):
+			d.Init = p.skip(i + 1)
+			d.End = p.scanTo(i, end, ",")
+			if p.toks[d.End].Is(",") {
+				d.End = p.prevCode(d.End - 1)
+			}
+			return d
+		case t.Is(",") || t.Is(":"):
+			return d
+		default:
+			return nil
+		}
+	}
+	return d
+}
@@ -0,0 +1,130 @@
+package parser
+
+import "strings"
+
+// Preproc is a preprocessor directive split into its name and argument
+type Preproc struct {
+	Name string // "define", "include", "ifdef"...
+	Arg  string // text after the name, with line continuations joined
+}
+
+// ParseDirective splits the text of a directive token
+func ParseDirective(text string) Preproc {
+	text = strings.ReplaceAll(text, "\\\r\n", " ")
+	text = strings.ReplaceAll(text, "\\\n", " ")
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "#"))
+
+	n := 0
+	for n < len(text) && isIdentChar(text[n]) {
+		n++
+	}
+	return Preproc{Name: text[:n], Arg: strings.TrimSpace(text[n:])}
+}
+
+// MacroName returns the name defined by a #define directive argument
+func (d Preproc) MacroName() string {
+	n := 0
+	for n < len(d.Arg) && isIdentChar(d.Arg[n]) {
+		n++
+	}
+	return d.Arg[:n]
+}
+
+// Macro is a #define directive split into its parts
+type Macro struct {
+	Name         string
+	FunctionLike bool     // a parameter list follows the name without a space
+	Params       []string // parameter names, "..." for the variadic part
+	Body         string   // replacement list, line continuations joined
+	Line         int
+	EndLine      int // last line of the directive, continuations included
+	Token        int // index of the directive token in the unit
+}
+
+// Macros returns the macros defined by the #define directives of the unit
+func (u *Unit) Macros() []Macro {
+	var macros []Macro
+	for i, t := range u.Tokens {
+		if t.Kind != Directive {
+			continue
+		}
+		d := ParseDirective(t.Text)
+		if d.Name != "define" {
+			continue
+		}
+		m := d.Macro()
+		if m.Name == "" {
+			continue
+		}
+		m.Line, m.EndLine, m.Token = t.Line, t.EndLine, i
+		macros = append(macros, m)
+	}
+	return macros
+}
+
+// Macro parses the argument of a #define directive. The position fields
+// of the result are left to the caller.
+func (d Preproc) Macro() Macro {
+	m := Macro{Name: d.MacroName()}
+	rest := d.Arg[len(m.Name):]
+	if !strings.HasPrefix(rest, "(") {
+		m.Body = strings.TrimSpace(rest)
+		return m
+	}
+
+	m.FunctionLike = true
+	end := strings.IndexByte(rest, ')')
+	if end < 0 {
+		end = len(rest)
+	} else {
+		m.Body = strings.TrimSpace(rest[end+1:])
+	}
+	for _, p := range strings.Split(rest[1:end], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			m.Params = append(m.Params, p)
+		}
+	}
+	return m
+}
+
+// Header returns the file named by an #include directive and whether it
+// is a system header (<...>) rather than a local one ("...")
+func (d Preproc) Header() (string, bool) {
+	arg := d.Arg
+	if len(arg) < 2 {
+		return "", false
+	}
+	var close byte = '"'
+	if arg[0] == '<' {
+		close = '>'
+	} else if arg[0] != '"' {
+		return "", false
+	}
+	end := strings.IndexByte(arg[1:], close)
+	if end < 0 {
+		return "", false
+	}
+	return arg[1 : end+1], close == '>'
+}
+
+// Calls returns the name tokens of the functions called from function
+// bodies. Member calls through '.' or '->' are not included.
+func (u *Unit) Calls() []int {
+	var calls []int
+	for _, fn := range u.Functions {
+		for i := fn.Open + 1; i < fn.Close; i++ {
+			if u.Tokens[i].Kind != Ident {
+				continue
+			}
+			next := u.Next(i + 1)
+			if next >= len(u.Tokens) || !u.Tokens[next].Is("(") {
+				continue
+			}
+			if prev := u.Prev(i - 1); prev >= 0 && (u.Tokens[prev].Is(".") || u.Tokens[prev].Is("->")) {
+				continue
+			}
+			calls = append(calls, i)
+		}
+	}
+	return calls
+}
@@ -0,0 +1,92 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/types"
+)
+
+// MetricColumns are the columns of the metrics table, usable as sort keys
+var MetricColumns = []string{"file", "function", "complexity", "nesting", "statements", "params", "lines"}
+
+// MetricRow is a line of the metrics table
+type MetricRow struct {
+	File string `json:"file"`
+	types.FunctionMetrics
+}
+
+// SortMetrics returns the metrics of every function of the report sorted
+// by column: alphabetically for file and function, highest first for the
+// measures
+func SortMetrics(report *types.Report, column string) ([]MetricRow, error) {
+	var rows []MetricRow
+	for _, file := range report.Files {
+		for _, m := range file.Metrics {
+			rows = append(rows, MetricRow{File: file.Filename, FunctionMetrics: m})
+		}
+	}
+
+	value := func(r MetricRow) int {
+		switch column {
+		case "complexity":
+			return r.Complexity
+		case "nesting":
+			return r.Nesting
+		case "statements":
+			return r.Statements
+		case "params":
+			return r.Params
+		}
+		return r.Lines
+	}
+
+	var less func(a, b MetricRow) bool
+	switch column {
+	case "file":
+		less = func(a, b MetricRow) bool { return a.File < b.File || (a.File == b.File && a.Line < b.Line) }
+	case "function":
+		less = func(a, b MetricRow) bool { return a.Name < b.Name }
+	case "complexity", "nesting", "statements", "params", "lines":
+		less = func(a, b MetricRow) bool { return value(a) > value(b) }
+	default:
+		return nil, fmt.Errorf("unknown metrics column %q (expected one of %s)", column, strings.Join(MetricColumns, ", "))
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+	return rows, nil
+}
+
+// PrintMetrics displays the metrics table of every function, sorted by
+// column
+func PrintMetrics(report *types.Report, column string) error {
+	rows, err := SortMetrics(report, column)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s%-24s %-28s %10s %11s %12s %8s %7s%s\n", types.ColorBold,
+		"FICHIER", "FONCTION", "COMPLEXITÉ", "IMBRICATION", "INSTRUCTIONS", "PARAMS", "LIGNES", types.ColorReset)
+	for _, r := range rows {
+		fmt.Printf("%-24s %-28s %10d %11d %12d %8d %7d\n",
+			truncate(r.File, 24), truncate(r.Name, 28), r.Complexity, r.Nesting, r.Statements, r.Params, r.Lines)
+	}
+	return nil
+}
+
+// truncate shortens s to width characters
+func truncate(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	return s[:width-1] + "…"
+}
+
+// printFunctionMetrics displays the metrics of the functions of a file
+func printFunctionMetrics(metrics []types.FunctionMetrics) {
+	for _, m := range metrics {
+		fmt.Printf("    ƒ %s (ligne %d) : complexité %d, imbrication %d, %d instructions, %d paramètres, %d lignes\n",
+			m.Name, m.Line, m.Complexity, m.Nesting, m.Statements, m.Params, m.Lines)
+	}
+}
@@ -13,6 +13,10 @@ func PrintReport(report *types.Report, verbose bool) {
 	printHeader()
 	printSummary(report)
 	printFileResults(report, verbose)
+	printRepository(report.Repository)
+	if verbose {
+		printExternalSymbols(report.ExternalSymbols)
+	}
 	printFinalScore(report)
 }
 
@@ -60,6 +64,9 @@ func printFileResults(report *types.Report, verbose bool) {
 		if verbose && len(file.Violations) > 0 {
 			printViolations(file.Violations)
 		}
+		if verbose && len(file.Metrics) > 0 {
+			printFunctionMetrics(file.Metrics)
+		}
 	}
 
 	fmt.Println()
@@ -72,13 +79,56 @@ func printViolations(violations []types.Violation) {
 		if v.Severity == "major" {
 			severity = types.ColorRed + "MAJOR" + types.ColorReset
 		}
-		fmt.Printf("    [%s] Line %d: %s - %s\n", severity, v.Line, v.Rule, v.Message)
+		position := fmt.Sprintf("Line %d", v.Line)
+		if v.Column > 0 {
+			position += fmt.Sprintf(", col %d", v.Column)
+		}
+		fmt.Printf("    [%s] %s: %s - %s\n", severity, position, v.Rule, v.Message)
 		if v.Description != "" {
 			fmt.Printf("         %s\n", v.Description)
 		}
 	}
 }
 
+// printRepository displays the violations of the project tree, which are
+// not attached to an analyzed file
+func printRepository(violations []types.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	fmt.Printf("🗂️  %sDÉPÔT%s (%d violations)\n", types.ColorBold, types.ColorReset, len(violations))
+	for _, v := range violations {
+		severity := types.ColorYellow + "MINOR" + types.ColorReset
+		if v.Severity == "major" {
+			severity = types.ColorRed + "MAJOR" + types.ColorReset
+		}
+		fmt.Printf("    [%s] %s: %s - %s\n", severity, v.File, v.Rule, v.Description)
+	}
+	fmt.Println()
+}
+
+// printExternalSymbols lists the library functions and system headers used
+func printExternalSymbols(symbols []types.SymbolUsage) {
+	if len(symbols) == 0 {
+		return
+	}
+
+	fmt.Printf("📚 %sSYMBOLES EXTERNES%s\n", types.ColorBold, types.ColorReset)
+	for _, sym := range symbols {
+		name := sym.Name
+		if sym.Kind == "header" {
+			name = "<" + name + ">"
+		}
+		color := types.ColorGreen
+		if sym.Forbidden {
+			color = types.ColorRed
+		}
+		fmt.Printf("   • %s%s%s (%d) - %s\n", color, name, types.ColorReset, sym.Count, strings.Join(sym.Files, ", "))
+	}
+	fmt.Println()
+}
+
 // printFinalScore displays the final score and message
 func printFinalScore(report *types.Report) {
 	scoreColor := types.ColorRed
@@ -0,0 +1,113 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// commentText returns the text of a comment token without its delimiters
+func commentText(text string) string {
+	if strings.HasPrefix(text, "//") {
+		return strings.TrimSpace(text[2:])
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	return strings.TrimSpace(text)
+}
+
+// LineComment is a "//" comment, which runs to the end of its line
+type LineComment struct {
+	Line      int    // 0-based index of the line
+	Col       int    // byte offset of the "//" in the line
+	Text      string // text without the "//" and surrounding spaces
+	Continued bool   // ends with a backslash, continuing on the next line
+}
+
+// LineComments returns the "//" comments of the unit, those written
+// after a preprocessor directive included. Strings and block comments
+// containing "//" are not comments.
+func LineComments(unit *parser.Unit) []LineComment {
+	var comments []LineComment
+	add := func(t parser.Token, line, col int) {
+		if t.Kind != parser.Comment || !strings.HasPrefix(t.Text, "//") {
+			return
+		}
+		comments = append(comments, LineComment{
+			Line:      line,
+			Col:       col,
+			Text:      commentText(t.Text),
+			Continued: strings.HasSuffix(strings.TrimRight(t.Text, " \t"), "\\"),
+		})
+	}
+
+	for _, t := range unit.Tokens {
+		if t.Kind != parser.Directive {
+			add(t, t.Line-1, t.Col-1)
+			continue
+		}
+		// Lex the directive after its '#' to find its trailing comment
+		for _, sub := range parser.Lex(t.Text[1:]) {
+			col := sub.Col - 1
+			if sub.Line == 1 {
+				col += t.Col
+			}
+			add(sub, t.Line+sub.Line-2, col)
+		}
+	}
+	return comments
+}
+
+// CheckFunctionBodyComments validates that function bodies hold no
+// comments, except those listed in the allowed_comments setting written
+// just before a case label of a switch, such as "fallthrough"
+func CheckFunctionBodyComments(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+
+	allowed := make(map[string]bool)
+	for _, c := range analysis.Settings().AllowedComments {
+		allowed[strings.ToLower(strings.TrimSpace(c))] = true
+	}
+
+	for _, fn := range unit.Functions {
+		var switches []*parser.Statement
+		fn.Walk(func(s *parser.Statement) {
+			if s.Kind == parser.StmtSwitch {
+				switches = append(switches, s)
+			}
+		})
+
+		for i := fn.Open + 1; i < fn.Close; i++ {
+			t := unit.Tokens[i]
+			if t.Kind != parser.Comment ||
+				(allowed[strings.ToLower(commentText(t.Text))] && beforeCaseLabel(unit, switches, i)) {
+				continue
+			}
+			violations = append(violations, types.Violation{
+				Rule:        "C-C3",
+				Message:     "Comment inside function",
+				Line:        t.Line,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Move the comments of '%s' above the function", fn.Name),
+			})
+		}
+	}
+	return violations
+}
+
+// beforeCaseLabel reports whether the next code token after a comment is a
+// case label of one of the switch statements
+func beforeCaseLabel(unit *parser.Unit, switches []*parser.Statement, comment int) bool {
+	next := unit.Next(comment + 1)
+	if next >= len(unit.Tokens) || !(unit.Tokens[next].Is("case") || unit.Tokens[next].Is("default")) {
+		return false
+	}
+	for _, s := range switches {
+		if s.HeadEnd < comment && next < s.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// symbolSite is the place a symbol is defined or declared
+type symbolSite struct {
+	File   string
+	Line   int
+	Static bool
+}
+
+// symbolTable indexes the functions and globals of every analyzed file
+type symbolTable struct {
+	functions  map[string][]symbolSite // function definitions
+	globals    map[string][]symbolSite // global variable definitions
+	prototypes map[string][]symbolSite // prototypes written in headers
+	references map[string]map[string]int
+}
+
+// buildSymbolTable reads the definitions, header prototypes and identifier
+// uses of the project sources
+func buildSymbolTable(sources []*types.FileAnalysis) *symbolTable {
+	table := &symbolTable{
+		functions:  make(map[string][]symbolSite),
+		globals:    make(map[string][]symbolSite),
+		prototypes: make(map[string][]symbolSite),
+		references: make(map[string]map[string]int),
+	}
+
+	for _, src := range sources {
+		unit := src.Unit()
+		header := filepath.Ext(src.Filename) == ".h"
+		declared := make(map[int]bool)
+
+		for _, fn := range unit.Functions {
+			declared[fn.NameTok] = true
+			table.functions[fn.Name] = append(table.functions[fn.Name], symbolSite{src.Filename, fn.Line, fn.Static})
+		}
+		for _, decl := range unit.Decls {
+			if decl.Typedef {
+				continue
+			}
+			for _, d := range decl.Declarators {
+				site := symbolSite{src.Filename, unit.Tokens[d.NameTok].Line, decl.Static}
+				switch {
+				case d.Function && !isFunctionPointer(unit, d):
+					declared[d.NameTok] = true
+					if header {
+						table.prototypes[d.Name] = append(table.prototypes[d.Name], site)
+					}
+				case !decl.Extern:
+					declared[d.NameTok] = true
+					table.globals[d.Name] = append(table.globals[d.Name], site)
+				}
+			}
+		}
+
+		for i, t := range unit.Tokens {
+			switch {
+			case t.Kind == parser.Ident && !declared[i]:
+				table.reference(t.Text, src.Filename)
+			case t.Kind == parser.Directive:
+				// Macro bodies may call functions
+				for _, mt := range parser.Lex(parser.ParseDirective(t.Text).Arg) {
+					if mt.Kind == parser.Ident {
+						table.reference(mt.Text, src.Filename)
+					}
+				}
+			}
+		}
+	}
+	return table
+}
+
+// reference records a use of name in file
+func (s *symbolTable) reference(name, file string) {
+	if s.references[name] == nil {
+		s.references[name] = make(map[string]int)
+	}
+	s.references[name][file]++
+}
+
+// isFunctionPointer reports whether a function declarator declares a
+// pointer variable, as in "int (*handler)(int)"
+func isFunctionPointer(unit *parser.Unit, d *parser.Declarator) bool {
+	next := unit.Next(d.NameTok + 1)
+	return next < len(unit.Tokens) && unit.Tokens[next].Is(")")
+}
+
+// sortedNames returns the keys of a site map in order
+func sortedNames(sites map[string][]symbolSite) []string {
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckUnusedFunctions reports non-static functions that no other file
+// references, which should be made static or removed
+func CheckUnusedFunctions(project *types.ProjectAnalysis) []types.Violation {
+	var violations []types.Violation
+	table := buildSymbolTable(project.Sources)
+
+	for _, name := range sortedNames(table.functions) {
+		if name == "main" {
+			continue
+		}
+		for _, site := range table.functions[name] {
+			if site.Static {
+				continue
+			}
+			refs := table.references[name]
+			elsewhere := 0
+			for file, n := range refs {
+				if file != site.File {
+					elsewhere += n
+				}
+			}
+			if elsewhere > 0 {
+				continue
+			}
+
+			description := fmt.Sprintf("Function '%s' is never used", name)
+			if refs[site.File] > 0 {
+				description = fmt.Sprintf("Function '%s' is only used in %s, declare it static", name, filepath.Base(site.File))
+			}
+			violations = append(violations, types.Violation{
+				Rule:        "C-X1",
+				Message:     "Unused exported function",
+				Line:        site.Line,
+				File:        site.File,
+				Severity:    "minor",
+				Description: description,
+			})
+		}
+	}
+	return violations
+}
+
+// CheckDuplicateDefinitions reports non-static functions and globals
+// defined more than once across the project
+func CheckDuplicateDefinitions(project *types.ProjectAnalysis) []types.Violation {
+	var violations []types.Violation
+	table := buildSymbolTable(project.Sources)
+
+	check := func(kind string, sites map[string][]symbolSite) {
+		for _, name := range sortedNames(sites) {
+			var first *symbolSite
+			for i, site := range sites[name] {
+				if site.Static {
+					continue
+				}
+				if first == nil {
+					first = &sites[name][i]
+					continue
+				}
+				violations = append(violations, types.Violation{
+					Rule:        "C-X2",
+					Message:     "Duplicate definition",
+					Line:        site.Line,
+					File:        site.File,
+					Severity:    "major",
+					Description: fmt.Sprintf("%s '%s' is already defined in %s:%d", kind, name, filepath.Base(first.File), first.Line),
+				})
+			}
+		}
+	}
+	check("Function", table.functions)
+	check("Global", table.globals)
+	return violations
+}
+
+// CheckOrphanPrototypes reports prototypes written in headers for
+// functions the project never defines
+func CheckOrphanPrototypes(project *types.ProjectAnalysis) []types.Violation {
+	var violations []types.Violation
+	table := buildSymbolTable(project.Sources)
+
+	for _, name := range sortedNames(table.prototypes) {
+		if len(table.functions[name]) > 0 {
+			continue
+		}
+		for _, site := range table.prototypes[name] {
+			violations = append(violations, types.Violation{
+				Rule:        "C-X3",
+				Message:     "Orphan prototype",
+				Line:        site.Line,
+				File:        site.File,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Function '%s' is declared but never defined", name),
+			})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// utf8BOM is the byte order mark some editors write at the start of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// rawContent returns the bytes the file was read from, or the lines joined
+// back, each ending with a newline, when the analysis was built without
+// them
+func rawContent(analysis *types.FileAnalysis) []byte {
+	if analysis.Content != nil {
+		return analysis.Content
+	}
+	if len(analysis.Lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(analysis.Lines, "\n") + "\n")
+}
+
+// CheckLineEndings validates that lines end with LF only (no CRLF or CR)
+func CheckLineEndings(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	content := rawContent(analysis)
+
+	crlf, cr := 0, 0
+	firstCRLF, firstCR := 0, 0
+	line := 1
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\n':
+			line++
+		case '\r':
+			if i+1 < len(content) && content[i+1] == '\n' {
+				crlf++
+				if firstCRLF == 0 {
+					firstCRLF = line
+				}
+			} else {
+				cr++
+				if firstCR == 0 {
+					firstCR = line
+				}
+				line++
+			}
+		}
+	}
+
+	if crlf > 0 {
+		violations = append(violations, types.Violation{
+			Rule:        "C-E1",
+			Message:     "CRLF line endings",
+			Line:        firstCRLF,
+			Severity:    "minor",
+			Description: fmt.Sprintf("%d line(s) end with CRLF, use LF only", crlf),
+		})
+	}
+	if cr > 0 {
+		violations = append(violations, types.Violation{
+			Rule:        "C-E1",
+			Message:     "CR line endings",
+			Line:        firstCR,
+			Severity:    "minor",
+			Description: fmt.Sprintf("%d line(s) end with a lone CR, use LF only", cr),
+		})
+	}
+	return violations
+}
+
+// CheckFinalNewline validates that a non-empty file ends with a newline
+func CheckFinalNewline(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	content := rawContent(analysis)
+	if len(content) == 0 || content[len(content)-1] == '\n' || content[len(content)-1] == '\r' {
+		return nil
+	}
+
+	return []types.Violation{{
+		Rule:        "C-E2",
+		Message:     "Missing newline at end of file",
+		Line:        len(analysis.Lines),
+		Severity:    "minor",
+		Description: "The last line of the file must end with a newline",
+	}}
+}
+
+// CheckEncoding validates that the file is UTF-8 without a byte order mark
+func CheckEncoding(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	content := rawContent(analysis)
+
+	if bytes.HasPrefix(content, utf8BOM) {
+		violations = append(violations, types.Violation{
+			Rule:        "C-E3",
+			Message:     "Byte order mark",
+			Line:        1,
+			Severity:    "minor",
+			Description: "The file starts with a UTF-8 byte order mark",
+		})
+	}
+
+	for i, line := range analysis.Lines {
+		if utf8.ValidString(line) {
+			continue
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-E3",
+			Message:     "Invalid UTF-8",
+			Line:        i + 1,
+			Severity:    "minor",
+			Description: "Line contains bytes that are not valid UTF-8",
+		})
+	}
+	return violations
+}
+
+// MidLineTabs returns, by line index, the byte offsets of the tab
+// characters written after the indentation of a line. Tabs inside string
+// and character literals belong to the program and are not returned.
+func MidLineTabs(unit *parser.Unit, lines []string) map[int][]int {
+	tabs := make(map[int][]int)
+
+	// Literal byte ranges, as offsets in the lines joined with "\n"
+	var literals [][2]int
+	for _, t := range unit.Tokens {
+		if t.Kind == parser.String || t.Kind == parser.Char {
+			literals = append(literals, [2]int{t.Offset, t.Offset + len(t.Text)})
+		}
+	}
+
+	start, lit := 0, 0
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for j := indent; j < len(line); j++ {
+			if line[j] != '\t' {
+				continue
+			}
+			off := start + j
+			for lit < len(literals) && literals[lit][1] <= off {
+				lit++
+			}
+			if lit < len(literals) && literals[lit][0] <= off {
+				continue
+			}
+			tabs[i] = append(tabs[i], j)
+		}
+		start += len(line) + 1
+	}
+	return tabs
+}
+
+// CheckMidLineTabs validates that tabs are only used for indentation
+func CheckMidLineTabs(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	tabs := MidLineTabs(analysis.Unit(), analysis.Lines)
+	tabWidth := analysis.Settings().TabWidth
+
+	for i, line := range analysis.Lines {
+		offsets := tabs[i]
+		if len(offsets) == 0 {
+			continue
+		}
+		column := types.Column(line, offsets[0], tabWidth)
+		violations = append(violations, types.Violation{
+			Rule:        "C-E4",
+			Message:     "Tab after indentation",
+			Line:        i + 1,
+			Column:      column,
+			Severity:    "minor",
+			Description: fmt.Sprintf("Tab character at column %d, use spaces to align", column),
+		})
+	}
+	return violations
+}
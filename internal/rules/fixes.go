@@ -0,0 +1,500 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// lineStarts returns the byte offset of each line of the analyzed content
+// in its raw form, followed by the length of the content
+func lineStarts(analysis *types.FileAnalysis) []int {
+	content := rawContent(analysis)
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\n':
+			starts = append(starts, i+1)
+		case '\r':
+			if i+1 < len(content) && content[i+1] == '\n' {
+				i++
+			}
+			starts = append(starts, i+1)
+		}
+	}
+	if len(starts) == 1 || starts[len(starts)-1] != len(content) {
+		starts = append(starts, len(content))
+	}
+	return starts
+}
+
+// replaceLines returns the edit replacing the text of the lines first to
+// last, 0-based, without the line break ending the last one
+func replaceLines(analysis *types.FileAnalysis, starts []int, first, last int, lines []string) types.Edit {
+	return types.Edit{
+		Start: starts[first],
+		End:   starts[last] + len(analysis.Lines[last]),
+		Text:  strings.Join(lines, "\n"),
+	}
+}
+
+// deleteLine returns the edit removing a line and its line break
+func deleteLine(starts []int, line int) types.Edit {
+	return types.Edit{Start: starts[line], End: starts[line+1]}
+}
+
+// tokenOffset returns the offset of a token in the raw content
+func tokenOffset(starts []int, t parser.Token) int {
+	return starts[t.Line-1] + t.Col - 1
+}
+
+// FixEncoding removes the byte order mark and converts the lines holding
+// bytes that are not valid UTF-8, assumed to be Latin-1, to UTF-8 (C-E3)
+func FixEncoding(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	content := rawContent(analysis)
+	starts := lineStarts(analysis)
+
+	if bytes.HasPrefix(content, utf8BOM) {
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-E3",
+			Description: "Removed byte order mark",
+			Line:        1,
+			Edits:       []types.Edit{{Start: 0, End: len(utf8BOM)}},
+		})
+	}
+
+	for i, line := range analysis.Lines {
+		if utf8.ValidString(line) {
+			continue
+		}
+		// The byte order mark is removed on its own
+		start := starts[i]
+		if i == 0 && bytes.HasPrefix(content, utf8BOM) {
+			start += len(utf8BOM)
+		}
+		var converted strings.Builder
+		for text := line[start-starts[i]:]; len(text) > 0; {
+			r, size := utf8.DecodeRuneInString(text)
+			if r == utf8.RuneError && size == 1 {
+				r = rune(text[0])
+			}
+			converted.WriteRune(r)
+			text = text[size:]
+		}
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-E3",
+			Description: "Converted Latin-1 characters to UTF-8",
+			Line:        i + 1,
+			Edits:       []types.Edit{{Start: start, End: starts[i] + len(line), Text: converted.String()}},
+		})
+	}
+	return fixes
+}
+
+// FixLineEndings converts CRLF and CR line endings to LF (C-E1)
+func FixLineEndings(analysis *types.FileAnalysis) []types.Fix {
+	content := rawContent(analysis)
+	var edits []types.Edit
+	for i := 0; i < len(content); i++ {
+		if content[i] != '\r' {
+			continue
+		}
+		if i+1 < len(content) && content[i+1] == '\n' {
+			edits = append(edits, types.Edit{Start: i, End: i + 1})
+		} else {
+			edits = append(edits, types.Edit{Start: i, End: i + 1, Text: "\n"})
+		}
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	return []types.Fix{{
+		Rule:        "C-E1",
+		Description: "Converted line endings to LF",
+		Line:        0,
+		Edits:       edits,
+	}}
+}
+
+// FixFinalNewline terminates the last line of the file (C-E2)
+func FixFinalNewline(analysis *types.FileAnalysis) []types.Fix {
+	content := rawContent(analysis)
+	if len(content) == 0 || content[len(content)-1] == '\n' || content[len(content)-1] == '\r' {
+		return nil
+	}
+	return []types.Fix{{
+		Rule:        "C-E2",
+		Description: "Added newline at end of file",
+		Line:        len(analysis.Lines),
+		Edits:       []types.Edit{{Start: len(content), End: len(content), Text: "\n"}},
+	}}
+}
+
+// FixEmptyLines removes the empty lines at the beginning and the end of
+// the file and those following another empty line (C-L2)
+func FixEmptyLines(analysis *types.FileAnalysis) []types.Fix {
+	lines := analysis.Lines
+	if len(rawContent(analysis)) == 0 {
+		return nil
+	}
+	starts := lineStarts(analysis)
+	empty := func(i int) bool { return strings.TrimSpace(lines[i]) == "" }
+
+	var fixes []types.Fix
+	remove := func(i int, description string) {
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-L2",
+			Description: description,
+			Line:        i + 1,
+			Edits:       []types.Edit{deleteLine(starts, i)},
+		})
+	}
+
+	first := 0
+	for first < len(lines) && empty(first) {
+		remove(first, "Removed empty line at beginning of file")
+		first++
+	}
+	end := len(lines)
+	for end > first && empty(end-1) {
+		end--
+	}
+	for i := first + 1; i < end; i++ {
+		if empty(i) && empty(i-1) {
+			remove(i, "Removed consecutive empty line")
+		}
+	}
+	for i := end; i < len(lines); i++ {
+		remove(i, "Removed empty line at end of file")
+	}
+	return fixes
+}
+
+// FixVariableDeclaration splits the declarations of several variables
+// into one declaration per line, each repeating the type (C-L4). The body
+// of a struct, union or enum stays with the first variable, the others
+// name its tag; declarations of an anonymous type are left unchanged.
+func FixVariableDeclaration(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	unit := analysis.Unit()
+	content := rawContent(analysis)
+	starts := lineStarts(analysis)
+	text := func(first, last int) string {
+		end := unit.Tokens[last]
+		return string(content[tokenOffset(starts, unit.Tokens[first]) : tokenOffset(starts, end)+len(end.Text)])
+	}
+
+	for _, decl := range unit.AllDeclarations() {
+		if len(decl.Declarators) < 2 || decl.End >= len(unit.Tokens) {
+			continue
+		}
+		start := unit.Next(decl.Start)
+		first := text(start, decl.TypeEnd)
+		rest := first
+		if open := typeBody(unit, decl); open >= 0 {
+			if decl.Tag == "" {
+				continue
+			}
+			rest = text(start, decl.TagTok)
+			if close := unit.Match(open); close >= 0 && close < decl.TypeEnd {
+				rest += " " + text(unit.Next(close+1), decl.TypeEnd)
+			}
+		}
+
+		indent := leadingWhitespace(analysis.Lines[decl.Line-1])
+		split := make([]string, 0, len(decl.Declarators))
+		for i, d := range decl.Declarators {
+			typ := rest
+			if i == 0 {
+				typ = first
+			}
+			split = append(split, typ+" "+text(d.Start, d.End)+";")
+		}
+		semi := unit.Tokens[decl.End]
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-L4",
+			Description: fmt.Sprintf("Split multiple variable declarations into %d lines", len(split)),
+			Line:        decl.Line,
+			Edits: []types.Edit{{
+				Start: tokenOffset(starts, unit.Tokens[start]),
+				End:   tokenOffset(starts, semi) + len(semi.Text),
+				Text:  strings.Join(split, "\n"+indent),
+			}},
+		})
+	}
+	return fixes
+}
+
+// typeBody returns the opening brace of the struct, union or enum body in
+// the type of a declaration, or -1 when there is none
+func typeBody(unit *parser.Unit, decl *parser.Declaration) int {
+	for i := decl.Start; i <= decl.TypeEnd; i++ {
+		if unit.Tokens[i].Is("{") {
+			return i
+		}
+	}
+	return -1
+}
+
+// FixPointerDeclarations attaches the pointer asterisks to the
+// identifier (C-V3)
+func FixPointerDeclarations(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	unit := analysis.Unit()
+	starts := lineStarts(analysis)
+
+	for _, span := range FindPointerSpans(unit) {
+		if !span.Misplaced(unit) {
+			continue
+		}
+		prev, next := unit.Tokens[span.Prev], unit.Tokens[span.Next]
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-V3",
+			Description: "Attached pointer asterisk to identifier",
+			Line:        unit.Tokens[span.First].Line,
+			Edits: []types.Edit{{
+				Start: tokenOffset(starts, prev) + len(prev.Text),
+				End:   tokenOffset(starts, next),
+				Text:  span.Replacement(),
+			}},
+		})
+	}
+	return fixes
+}
+
+// FixExplicitVoid writes "(void)" for empty parameter lists (C-F5)
+func FixExplicitVoid(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	unit := analysis.Unit()
+	starts := lineStarts(analysis)
+
+	add := func(open, line int) {
+		at := tokenOffset(starts, unit.Tokens[open]) + 1
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-F5",
+			Description: "Added void to empty parameter list",
+			Line:        line,
+			Edits:       []types.Edit{{Start: at, End: at, Text: "void"}},
+		})
+	}
+	for _, fn := range unit.Functions {
+		if unit.Next(fn.ParamOpen+1) == fn.ParamClose {
+			add(fn.ParamOpen, fn.Line)
+		}
+	}
+	for _, list := range PrototypeEmptyParams(unit) {
+		add(list.Open, unit.Tokens[list.Open].Line)
+	}
+	return fixes
+}
+
+// FixCommentFormat converts // comments to /* */ (C-C1). Consecutive
+// comments alone on their lines are merged into one block, "*/" in the
+// text is escaped and comments continued by a backslash are left alone.
+func FixCommentFormat(analysis *types.FileAnalysis) []types.Fix {
+	lines := analysis.Lines
+	comments := LineComments(analysis.Unit())
+	if len(comments) == 0 {
+		return nil
+	}
+	starts := lineStarts(analysis)
+
+	// ownLine reports whether nothing but indentation precedes a comment
+	ownLine := func(c LineComment) bool {
+		return strings.TrimSpace(lines[c.Line][:c.Col]) == ""
+	}
+
+	var fixes []types.Fix
+	for i := 0; i < len(comments); i++ {
+		c := comments[i]
+		if c.Continued {
+			fixes = append(fixes, types.Fix{
+				Rule:        "C-C1",
+				Description: "Left // comment ending with a backslash unchanged, it continues on the next line",
+				Line:        c.Line + 1,
+			})
+			continue
+		}
+
+		// Gather the comments alone on the following lines
+		group := []LineComment{c}
+		indent := lines[c.Line][:c.Col]
+		for ownLine(c) && i+1 < len(comments) {
+			n := comments[i+1]
+			if n.Line != group[len(group)-1].Line+1 || n.Continued || !ownLine(n) || lines[n.Line][:n.Col] != indent {
+				break
+			}
+			group = append(group, n)
+			i++
+		}
+
+		escaped := false
+		text := func(c LineComment) string {
+			if strings.Contains(c.Text, "*/") {
+				escaped = true
+				return strings.ReplaceAll(c.Text, "*/", "* /")
+			}
+			return c.Text
+		}
+
+		description := "Converted // comment to /* */"
+		var converted []string
+		switch {
+		case len(group) > 1:
+			converted = append(converted, indent+"/*")
+			for _, g := range group {
+				converted = append(converted, strings.TrimRight(indent+"** "+text(g), " "))
+			}
+			converted = append(converted, indent+"*/")
+			description = fmt.Sprintf("Merged %d // comments into a /* */ block", len(group))
+		case c.Text == "":
+			converted = append(converted, strings.TrimRight(indent, " \t"))
+		default:
+			converted = append(converted, indent+"/* "+text(c)+" */")
+		}
+		if escaped {
+			description += ", escaped '*/'"
+		}
+
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-C1",
+			Description: description,
+			Line:        c.Line + 1,
+			Edits:       []types.Edit{replaceLines(analysis, starts, c.Line, group[len(group)-1].Line, converted)},
+		})
+	}
+	return fixes
+}
+
+// forDeclaration matches a for loop declaring its variable of a basic type
+var forDeclaration = regexp.MustCompile(`^\s*for\s*\(\s*(int|char|float|double)\s+([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*([^;]+);(.*)$`)
+
+// FixForLoopDeclaration declares the variable of a for loop before the
+// loop (C-L5)
+func FixForLoopDeclaration(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	starts := lineStarts(analysis)
+
+	for i, line := range analysis.Lines {
+		matches := forDeclaration.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		indent := leadingWhitespace(line)
+		varType, varName := matches[1], matches[2]
+		initValue, rest := strings.TrimSpace(matches[3]), matches[4]
+
+		extracted := []string{
+			indent + varType + " " + varName + ";",
+			"",
+			indent + "for (" + varName + " = " + initValue + ";" + rest,
+		}
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-L5",
+			Description: "Extracted variable declaration from for loop",
+			Line:        i + 1,
+			Edits:       []types.Edit{replaceLines(analysis, starts, i, i, extracted)},
+		})
+	}
+	return fixes
+}
+
+// FixMidLineTabs replaces the tabs written after the indentation with the
+// spaces reaching the same tab stop (C-E4)
+func FixMidLineTabs(analysis *types.FileAnalysis) []types.Fix {
+	lines := analysis.Lines
+	tabs := MidLineTabs(analysis.Unit(), lines)
+	if len(tabs) == 0 {
+		return nil
+	}
+	starts := lineStarts(analysis)
+	tabWidth := analysis.Settings().TabWidth
+
+	var fixes []types.Fix
+	for i, line := range lines {
+		offsets := tabs[i]
+		if len(offsets) == 0 {
+			continue
+		}
+		var edits []types.Edit
+		for _, j := range offsets {
+			col := types.Column(line, j, tabWidth) - 1
+			edits = append(edits, types.Edit{
+				Start: starts[i] + j,
+				End:   starts[i] + j + 1,
+				Text:  strings.Repeat(" ", tabWidth-col%tabWidth),
+			})
+		}
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-E4",
+			Description: fmt.Sprintf("Replaced %d tab(s) after indentation with spaces", len(offsets)),
+			Line:        i + 1,
+			Edits:       edits,
+		})
+	}
+	return fixes
+}
+
+// FixFilename renames a file to snake_case (C-O1)
+func FixFilename(analysis *types.FileAnalysis) []types.Fix {
+	base := filepath.Base(analysis.Filename)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	if types.IsSnakeCase(name) {
+		return nil
+	}
+	newName := types.ToSnakeCase(name) + ext
+	return []types.Fix{{
+		Rule:        "C-O1",
+		Description: fmt.Sprintf("Rename file to %s", newName),
+		Line:        0,
+		Rename:      newName,
+	}}
+}
+
+// symbolFix returns the project-wide rename of a badly cased symbol, or
+// the reason it is not renamed when its expected name is a keyword
+func symbolFix(rule, kind, name, newName string, line int) types.Fix {
+	fix := types.Fix{
+		Rule:        rule,
+		Description: fmt.Sprintf("Rename %s '%s' to '%s'", kind, name, newName),
+		Line:        line,
+		Rename:      newName,
+	}
+	if parser.IsKeyword(newName) {
+		fix.Description = fmt.Sprintf("Not renamed %s '%s': '%s' is a keyword", kind, name, newName)
+		fix.Rename = ""
+	}
+	return fix
+}
+
+// FixFunctionNames renames the functions to snake_case, in every file of
+// the project or only in their own for static ones (C-F1)
+func FixFunctionNames(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	for _, fn := range analysis.Functions {
+		if !types.IsSnakeCase(fn.Name) && fn.Name != "main" {
+			fixes = append(fixes, symbolFix("C-F1", "function", fn.Name, types.ToSnakeCase(fn.Name), fn.StartLine))
+		}
+	}
+	return fixes
+}
+
+// FixMacroNames renames the macros to SCREAMING_SNAKE_CASE, in every file
+// of the project or only in their own for those of .c files (C-F2)
+func FixMacroNames(analysis *types.FileAnalysis) []types.Fix {
+	var fixes []types.Fix
+	for _, m := range analysis.Unit().Macros() {
+		if !types.IsScreamingSnakeCase(m.Name) {
+			fixes = append(fixes, symbolFix("C-F2", "macro", m.Name, types.ToScreamingSnakeCase(m.Name), m.Line))
+		}
+	}
+	return fixes
+}
@@ -0,0 +1,305 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// statement is the statement being read at some point of a file
+type statement struct {
+	first  parser.Token // first token
+	prev   parser.Token // last token
+	tokens int          // tokens read, 0 when the previous statement ended
+	enum   bool         // the statement declares an enumeration
+	head   bool         // the statement is a complete control head, such as 'if (x)'
+}
+
+// indentBlock is a brace block enclosing a line
+type indentBlock struct {
+	list   bool      // initializer or enumeration, whose items end with commas
+	swtch  bool      // body of a switch
+	inCase bool      // a case label of the switch was read
+	heads  int       // unbraced control heads the block is the body of
+	outer  statement // statement containing a list, resumed after it
+}
+
+// blockState is the block structure at some point of a file
+type blockState struct {
+	blocks []indentBlock
+	parens int // open parentheses and brackets
+	heads  int // unbraced control heads of the current block, such as 'if (a)' before 'if (b)\n\tx();'
+	stmt   statement
+}
+
+// clone returns a copy of the state sharing nothing with it
+func (s blockState) clone() blockState {
+	s.blocks = append([]indentBlock(nil), s.blocks...)
+	return s
+}
+
+// top returns the innermost block, or nil at file scope
+func (s *blockState) top() *indentBlock {
+	if len(s.blocks) == 0 {
+		return nil
+	}
+	return &s.blocks[len(s.blocks)-1]
+}
+
+// level returns the indentation level of a line starting with t: one per
+// enclosing block, one more for the statements following a case label,
+// one per unbraced control head the line is the body of, and one more for
+// the continuation lines of an unfinished statement
+func (s *blockState) level(t parser.Token) int {
+	level := s.heads
+	for _, b := range s.blocks {
+		level += 1 + b.heads
+		if b.swtch && b.inCase {
+			level++
+		}
+	}
+
+	top := s.top()
+	switch {
+	case t.Is("}") && top != nil:
+		level--
+		if top.swtch && top.inCase {
+			level--
+		}
+	case (t.Is("case") || t.Is("default")) && s.stmt.tokens == 0 && top != nil && top.swtch && top.inCase:
+		level--
+	case s.stmt.tokens > 0 && !t.Is("{"):
+		level++
+	}
+	return level
+}
+
+// note adds a token to the current statement
+func (s *blockState) note(t parser.Token) {
+	if s.stmt.tokens == 0 {
+		s.stmt.first = t
+		s.stmt.head = t.Is("else") || t.Is("do")
+	}
+	s.stmt.tokens++
+	s.stmt.prev = t
+	if t.Is("enum") {
+		s.stmt.enum = true
+	}
+}
+
+// endStatement finishes the current statement, with the unbraced control
+// heads it is the body of
+func (s *blockState) endStatement() {
+	s.stmt = statement{}
+	s.heads = 0
+}
+
+// isControlHead reports whether a statement starting with t has a head
+// ending with a parenthesis
+func isControlHead(t parser.Token) bool {
+	return t.Is("if") || t.Is("while") || t.Is("for") || t.Is("switch")
+}
+
+// isLabel reports whether a colon ends the current statement as a case or
+// goto label
+func (s *blockState) isLabel() bool {
+	first := s.stmt.first
+	return first.Is("case") || first.Is("default") || (s.stmt.tokens == 1 && first.Kind == parser.Ident)
+}
+
+// advance updates the state past a token of code
+func (s *blockState) advance(t parser.Token) {
+	// A control head followed by anything but a brace has an unbraced
+	// body, one level deeper; 'else if' stays at the level of its 'else'
+	if s.stmt.head && !t.Is("{") {
+		if !(s.stmt.first.Is("else") && t.Is("if")) {
+			s.heads++
+		}
+		s.stmt = statement{}
+	}
+
+	top := s.top()
+	switch {
+	case t.Is("{"):
+		prev := s.stmt.prev
+		b := indentBlock{outer: s.stmt, swtch: s.stmt.first.Is("switch"), heads: s.heads}
+		b.list = s.stmt.enum || s.parens > 0 ||
+			(s.stmt.tokens > 0 && (prev.Is("=") || prev.Is(",") || prev.Is("return"))) ||
+			(s.stmt.tokens == 0 && top != nil && top.list)
+		s.blocks = append(s.blocks, b)
+		s.stmt = statement{}
+		s.heads = 0
+	case t.Is("}"):
+		if top == nil {
+			return
+		}
+		s.blocks = s.blocks[:len(s.blocks)-1]
+		s.endStatement()
+		if top.list {
+			s.stmt = top.outer
+			s.heads = top.heads
+			s.note(t)
+		}
+	case t.Is("(") || t.Is("["):
+		s.parens++
+		s.note(t)
+	case t.Is(")") || t.Is("]"):
+		s.parens = max(s.parens-1, 0)
+		s.note(t)
+		if s.parens == 0 && t.Is(")") && isControlHead(s.stmt.first) {
+			s.stmt.head = true
+		}
+	case t.Is(";") && s.parens == 0:
+		s.endStatement()
+	case t.Is(",") && s.parens == 0 && top != nil && top.list:
+		s.stmt = statement{}
+	case t.Is(":") && s.parens == 0 && s.isLabel():
+		if s.stmt.first.Kind == parser.Keyword && top != nil && top.swtch {
+			top.inCase = true
+		}
+		s.endStatement()
+	default:
+		s.note(t)
+	}
+}
+
+// continuationLevel returns the indentation level of the lines continuing
+// a line starting with t
+func (s *blockState) continuationLevel(t parser.Token) int {
+	level := s.level(t)
+	if s.stmt.tokens == 0 || t.Is("{") {
+		level++
+	}
+	return level
+}
+
+// walkBlocks calls visit for every token of a unit with the block state
+// before it. The branches of a conditional directive each start from the
+// state before the #if, so that their braces are counted once.
+func walkBlocks(unit *parser.Unit, visit func(i int, t parser.Token, state *blockState)) {
+	var state blockState
+	var saved []blockState
+	for i, t := range unit.Tokens {
+		visit(i, t, &state)
+		switch t.Kind {
+		case parser.Comment:
+		case parser.Directive:
+			switch parser.ParseDirective(t.Text).Name {
+			case "if", "ifdef", "ifndef":
+				saved = append(saved, state.clone())
+			case "elif", "else":
+				if len(saved) > 0 {
+					state = saved[len(saved)-1].clone()
+				}
+			case "endif":
+				if len(saved) > 0 {
+					saved = saved[:len(saved)-1]
+				}
+			}
+		default:
+			state.advance(t)
+		}
+	}
+}
+
+// reindent is the indentation expected for a line
+type reindent struct {
+	prefix      int    // bytes replaced at the start of the line
+	text        string // replacement
+	description string
+}
+
+// FixIndentation re-indents the lines from the block structure of the
+// file (C-L3): one level per enclosing brace, one more after the case
+// labels of a switch, one per unbraced control statement a line is the
+// body of, and one more for the continuation lines of a statement. Levels
+// are written with tabs, or 4 spaces under the official profile. Directives start at the first column and are indented after
+// the '#' by their #if nesting. The continuation lines of multi-line
+// comments are aligned with their first line; those of strings and
+// directives are left unchanged.
+func FixIndentation(analysis *types.FileAnalysis) []types.Fix {
+	cfg := analysis.Settings()
+	unit := analysis.Unit()
+	lines := analysis.Lines
+
+	expected := make([]*reindent, len(lines))
+	indentOf := func(l int) string {
+		if r := expected[l]; r != nil {
+			return r.text
+		}
+		return leadingWhitespace(lines[l])
+	}
+
+	directives := directiveLevels(unit)
+	walkBlocks(unit, func(i int, t parser.Token, state *blockState) {
+		l := t.Line - 1
+		if l >= len(lines) {
+			return
+		}
+		if expected[l] == nil && t.Col-1 == len(leadingWhitespace(lines[l])) {
+			if t.Kind == parser.Directive {
+				expected[l] = directiveReindent(lines[l], directives, i, cfg.TabWidth)
+			} else {
+				level := state.level(t)
+				expected[l] = &reindent{
+					prefix:      len(leadingWhitespace(lines[l])),
+					text:        strings.Repeat(cfg.IndentUnit(), level),
+					description: fmt.Sprintf("Reindented line to level %d", level),
+				}
+			}
+		}
+
+		// Lines continuing a multi-line token
+		for c := l + 1; c < t.EndLine && c < len(lines); c++ {
+			ws := leadingWhitespace(lines[c])
+			expected[c] = &reindent{prefix: len(ws), text: ws}
+			if t.Kind == parser.Comment && strings.HasPrefix(lines[c][len(ws):], "*") {
+				expected[c].text = indentOf(l)
+				expected[c].description = "Aligned comment line with its opening"
+			}
+		}
+	})
+
+	// Whitespace left on empty lines
+	for l, line := range lines {
+		if expected[l] == nil && line != "" && strings.TrimSpace(line) == "" {
+			expected[l] = &reindent{prefix: len(line), description: "Removed whitespace of empty line"}
+		}
+	}
+
+	var fixes []types.Fix
+	starts := lineStarts(analysis)
+	for l, r := range expected {
+		if r == nil || lines[l][:r.prefix] == r.text {
+			continue
+		}
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-L3",
+			Description: r.description,
+			Line:        l + 1,
+			Edits:       []types.Edit{{Start: starts[l], End: starts[l] + r.prefix, Text: r.text}},
+		})
+	}
+	return fixes
+}
+
+// directiveReindent returns the expected indentation of a directive: the '#'
+// at the first column, followed by one level of spaces per #if nesting.
+// The directives of the include guard keep their spacing.
+func directiveReindent(line string, levels map[int]int, index, tabWidth int) *reindent {
+	hash := strings.IndexByte(line, '#')
+	after := line[hash+1:]
+	name := hash + 1 + len(after) - len(strings.TrimLeft(after, " \t"))
+
+	level, ok := levels[index]
+	if !ok {
+		return &reindent{prefix: hash, description: "Moved directive to the first column"}
+	}
+	return &reindent{
+		prefix:      name,
+		text:        "#" + strings.Repeat(" ", level*tabWidth),
+		description: fmt.Sprintf("Reindented directive to level %d", level),
+	}
+}
@@ -0,0 +1,175 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// CheckStatementPerLine ensures each line holds at most one statement
+func CheckStatementPerLine(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+	reported := make(map[int]bool)
+
+	report := func(line int) {
+		if reported[line] {
+			return
+		}
+		reported[line] = true
+		violations = append(violations, types.Violation{
+			Rule:        "C-L6",
+			Message:     "Multiple statements on one line",
+			Line:        line,
+			Severity:    "major",
+			Description: "Only one statement is allowed per line",
+		})
+	}
+
+	// File scope: declarations and function definitions sharing a line
+	type item struct{ start, line, endLine int }
+	var items []item
+	for _, decl := range unit.Decls {
+		items = append(items, item{decl.Start, decl.Line, decl.EndLine})
+	}
+	for _, fn := range unit.Functions {
+		items = append(items, item{fn.Start, fn.Line, fn.EndLine})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].start < items[j].start })
+	for i := 1; i < len(items); i++ {
+		if items[i].line == items[i-1].endLine {
+			report(items[i].line)
+		}
+	}
+
+	for _, fn := range unit.Functions {
+		checkStatementSequence(unit, fn.Body, unit.Tokens[fn.Open].Line, report)
+	}
+
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Line < violations[j].Line })
+	return violations
+}
+
+// checkStatementSequence reports statements starting on the line where the
+// previous one (or the opening brace) ends. A statement following a case
+// or goto label on the same line is tolerated.
+func checkStatementSequence(unit *parser.Unit, stmts []*parser.Statement, prevLine int, report func(int)) {
+	afterLabel := false
+	for _, s := range stmts {
+		if s.Kind != parser.StmtEmpty && s.Line == prevLine && !afterLabel {
+			report(s.Line)
+		}
+		afterLabel = s.Kind == parser.StmtCase || s.Kind == parser.StmtLabel
+		checkNestedStatements(unit, s, report)
+		prevLine = s.EndLine
+	}
+}
+
+// checkNestedStatements descends into blocks and controlled statements
+func checkNestedStatements(unit *parser.Unit, s *parser.Statement, report func(int)) {
+	switch s.Kind {
+	case parser.StmtBlock:
+		checkStatementSequence(unit, s.Body, unit.Tokens[s.Start].Line, report)
+	case parser.StmtIf, parser.StmtFor, parser.StmtWhile, parser.StmtSwitch, parser.StmtDo:
+		if len(s.Body) > 0 && s.HeadEnd >= 0 {
+			checkControlledStatement(unit, s.Body[0], unit.Tokens[s.HeadEnd].EndLine, report)
+		}
+		if s.Else != nil {
+			if s.Else.Kind == parser.StmtIf {
+				// "else if" chains are a single construct
+				checkNestedStatements(unit, s.Else, report)
+			} else {
+				checkControlledStatement(unit, s.Else, unit.Tokens[s.ElseTok].Line, report)
+			}
+		}
+	}
+}
+
+// checkControlledStatement reports a non-block body written on the same
+// line as its if/else/loop head
+func checkControlledStatement(unit *parser.Unit, body *parser.Statement, headLine int, report func(int)) {
+	if body.Kind != parser.StmtBlock && body.Kind != parser.StmtEmpty && body.Line == headLine {
+		report(body.Line)
+	}
+	checkNestedStatements(unit, body, report)
+}
+
+// CheckLineBreaks requires exactly one empty line after the declarations of
+// a function and exactly one empty line between function definitions
+func CheckLineBreaks(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+
+	for _, fn := range unit.Functions {
+		decls := 0
+		for decls < len(fn.Body) && fn.Body[decls].Kind == parser.StmtDecl {
+			decls++
+		}
+		if decls == 0 || decls == len(fn.Body) {
+			continue
+		}
+		first := fn.Body[decls]
+		blank := countBlankLines(analysis.Lines, fn.Body[decls-1].EndLine, first.Line)
+		if blank != 1 {
+			violations = append(violations, types.Violation{
+				Rule:        "C-L7",
+				Message:     emptyLinesMessage(blank, "after declarations"),
+				Line:        first.Line,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Function '%s' needs exactly one empty line between declarations and statements (found %d)", fn.Name, blank),
+			})
+		}
+	}
+
+	for i := 1; i < len(unit.Functions); i++ {
+		prev, fn := unit.Functions[i-1], unit.Functions[i]
+		if hasCodeBetween(unit, prev.Close, fn.Start) {
+			continue
+		}
+		blank := countBlankLines(analysis.Lines, prev.EndLine, fn.Line)
+		if blank != 1 {
+			violations = append(violations, types.Violation{
+				Rule:        "C-L7",
+				Message:     emptyLinesMessage(blank, "between functions"),
+				Line:        fn.Line,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Function '%s' must be separated from the previous one by exactly one empty line (found %d)", fn.Name, blank),
+			})
+		}
+	}
+
+	return violations
+}
+
+// emptyLinesMessage describes a separation of blank lines instead of one
+func emptyLinesMessage(blank int, where string) string {
+	if blank == 0 {
+		return "Missing empty line " + where
+	}
+	return "Too many empty lines " + where
+}
+
+// countBlankLines counts the blank lines strictly between two 1-based lines
+func countBlankLines(lines []string, from, to int) int {
+	count := 0
+	for l := from + 1; l < to && l <= len(lines); l++ {
+		if strings.TrimSpace(lines[l-1]) == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// hasCodeBetween reports whether anything other than comments separates
+// two tokens
+func hasCodeBetween(unit *parser.Unit, from, to int) bool {
+	for i := from + 1; i < to; i++ {
+		if unit.Tokens[i].Kind != parser.Comment {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// CheckMacroNames validates macro names are in SCREAMING_SNAKE_CASE
+func CheckMacroNames(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	for _, m := range analysis.Unit().Macros() {
+		if !types.IsScreamingSnakeCase(m.Name) {
+			violations = append(violations, types.Violation{
+				Rule:        "C-F2",
+				Message:     "Invalid macro name",
+				Line:        m.Line,
+				Severity:    "major",
+				Description: fmt.Sprintf("Macro '%s' must be in SCREAMING_SNAKE_CASE", m.Name),
+			})
+		}
+	}
+	return violations
+}
+
+// CheckMultilineMacros validates that macros fit on a single line
+func CheckMultilineMacros(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	for _, m := range analysis.Unit().Macros() {
+		if m.EndLine > m.Line {
+			violations = append(violations, types.Violation{
+				Rule:        "C-P1",
+				Message:     "Multi-line macro",
+				Line:        m.Line,
+				Severity:    "major",
+				Description: fmt.Sprintf("Macro '%s' spans %d lines, macros must fit on one line", m.Name, m.EndLine-m.Line+1),
+			})
+		}
+	}
+	return violations
+}
+
+// macroStatements counts the statements of a macro replacement list: the
+// semicolons outside parentheses, plus the code following the last one
+func macroStatements(body string) int {
+	count, depth := 0, 0
+	pending := false
+	for _, t := range parser.Lex(body) {
+		switch {
+		case t.Kind == parser.Comment:
+			continue
+		case t.Is("("):
+			depth++
+		case t.Is(")"):
+			depth--
+		case t.Is(";") && depth == 0:
+			count++
+			pending = false
+			continue
+		}
+		pending = true
+	}
+	if pending {
+		count++
+	}
+	return count
+}
+
+// CheckMacroStatements validates that a macro holds at most one statement
+func CheckMacroStatements(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	for _, m := range analysis.Unit().Macros() {
+		if n := macroStatements(m.Body); n > 1 {
+			violations = append(violations, types.Violation{
+				Rule:        "C-P2",
+				Message:     "Macro with several statements",
+				Line:        m.Line,
+				Severity:    "major",
+				Description: fmt.Sprintf("Macro '%s' contains %d statements, use a function instead", m.Name, n),
+			})
+		}
+	}
+	return violations
+}
+
+// includeGuard returns the indexes of the directives forming the include
+// guard of the file (#ifndef, #define and the final #endif), or nil
+func includeGuard(unit *parser.Unit) map[int]bool {
+	var directives []int
+	for i, t := range unit.Tokens {
+		if t.Kind == parser.Directive {
+			directives = append(directives, i)
+		}
+	}
+	if len(directives) < 3 {
+		return nil
+	}
+
+	first := parser.ParseDirective(unit.Tokens[directives[0]].Text)
+	second := parser.ParseDirective(unit.Tokens[directives[1]].Text)
+	last := parser.ParseDirective(unit.Tokens[directives[len(directives)-1]].Text)
+	if first.Name != "ifndef" || second.Name != "define" || last.Name != "endif" ||
+		second.MacroName() != strings.TrimSpace(first.Arg) {
+		return nil
+	}
+
+	// The code after the final #endif is not guarded
+	for _, t := range unit.Tokens[directives[len(directives)-1]+1:] {
+		if t.Kind != parser.Comment {
+			return nil
+		}
+	}
+	return map[int]bool{directives[0]: true, directives[1]: true, directives[len(directives)-1]: true}
+}
+
+// directiveIndent returns the display width of the indentation of a
+// directive, counting the spaces before and after the '#'
+func directiveIndent(line string, tabWidth int) int {
+	hash := strings.IndexByte(line, '#')
+	if hash < 0 {
+		return 0
+	}
+	after := line[hash+1:]
+	name := len(after) - len(strings.TrimLeft(after, " \t"))
+	return types.Column(line, hash, tabWidth) - 1 + types.DisplayWidth(after[:name], tabWidth)
+}
+
+// directiveLevels returns the expected nesting level of the directives of
+// a file, by token index. The include guard of a header does not count as
+// a block and is left out.
+func directiveLevels(unit *parser.Unit) map[int]int {
+	levels := make(map[int]int)
+	guard := includeGuard(unit)
+
+	depth := 0
+	for i, t := range unit.Tokens {
+		if t.Kind != parser.Directive || guard[i] {
+			continue
+		}
+		level := depth
+		switch parser.ParseDirective(t.Text).Name {
+		case "if", "ifdef", "ifndef":
+			depth++
+		case "elif", "else":
+			level--
+		case "endif":
+			depth--
+			level--
+		}
+		levels[i] = max(level, 0)
+		depth = max(depth, 0)
+	}
+	return levels
+}
+
+// CheckDirectiveIndentation validates that preprocessor directives nested
+// in conditional blocks are indented by one level per block. The include
+// guard of a header does not count as a block.
+func CheckDirectiveIndentation(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+	tabWidth := analysis.Settings().TabWidth
+
+	levels := directiveLevels(unit)
+	for i, t := range unit.Tokens {
+		level, ok := levels[i]
+		if !ok || t.Line-1 >= len(analysis.Lines) {
+			continue
+		}
+		indent := directiveIndent(analysis.Lines[t.Line-1], tabWidth)
+		if want := level * tabWidth; indent != want {
+			violations = append(violations, types.Violation{
+				Rule:        "C-P3",
+				Message:     "Misindented directive",
+				Line:        t.Line,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Directive '#%s' is indented by %d columns, expected %d", parser.ParseDirective(t.Text).Name, indent, want),
+			})
+		}
+	}
+	return violations
+}
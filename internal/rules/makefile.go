@@ -0,0 +1,176 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// requiredTargets are the rules every delivered Makefile must provide
+var requiredTargets = []string{"all", "clean", "fclean", "re"}
+
+// phonyTargets are the conventional targets that never produce a file
+var phonyTargets = []string{"all", "clean", "fclean", "re", "bonus", "debug", "install", "tests_run"}
+
+// nameTarget returns the rule building $(NAME), or nil
+func nameTarget(mk *parser.Makefile) *parser.MakeRule {
+	if r := mk.Target("$(NAME)"); r != nil {
+		return r
+	}
+	return mk.Target("${NAME}")
+}
+
+// CheckMakefileTargets validates that a Makefile defines NAME and the
+// all, clean, fclean, re and $(NAME) rules. Included *.mk fragments are
+// not required to.
+func CheckMakefileTargets(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	if filepath.Ext(filename) == ".mk" {
+		return violations
+	}
+	mk := analysis.Makefile()
+
+	missing := func(what string) {
+		violations = append(violations, types.Violation{
+			Rule:        "C-MK1",
+			Message:     "Missing Makefile rule",
+			Line:        1,
+			Severity:    "major",
+			Description: fmt.Sprintf("The Makefile must provide %s", what),
+		})
+	}
+
+	if _, ok := mk.Variables["NAME"]; !ok {
+		missing("the NAME variable")
+	}
+	for _, target := range requiredTargets {
+		if mk.Target(target) == nil {
+			missing(fmt.Sprintf("the '%s' rule", target))
+		}
+	}
+	if nameTarget(mk) == nil {
+		missing("the '$(NAME)' rule")
+	}
+	return violations
+}
+
+// CheckMakefilePhony validates that the conventional non-file targets are
+// declared .PHONY
+func CheckMakefilePhony(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	mk := analysis.Makefile()
+
+	for _, target := range phonyTargets {
+		rule := mk.Target(target)
+		if rule == nil || mk.Phony[target] {
+			continue
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-MK2",
+			Message:     "Missing .PHONY",
+			Line:        rule.Line,
+			Severity:    "minor",
+			Description: fmt.Sprintf("Target '%s' does not build a file and must be declared .PHONY", target),
+		})
+	}
+	return violations
+}
+
+// sourceGlob matches the constructs listing source files implicitly
+var sourceGlob = regexp.MustCompile(`\$[({](wildcard|shell\s+(find|ls))\b|\S*\*\S*\.c\b`)
+
+// CheckMakefileWildcards validates that source files are listed
+// explicitly rather than with wildcard, find or ls
+func CheckMakefileWildcards(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	for _, l := range analysis.Makefile().Lines {
+		match := sourceGlob.FindString(l.Text)
+		if match == "" {
+			continue
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-MK3",
+			Message:     "Wildcard source list",
+			Line:        l.Line,
+			Severity:    "major",
+			Description: fmt.Sprintf("List source files explicitly instead of '%s'", strings.TrimSpace(match)),
+		})
+	}
+	return violations
+}
+
+// epitechYear matches the second line of the Epitech header
+var epitechYear = regexp.MustCompile(`^## EPITECH PROJECT, [0-9]{4}$`)
+
+// CheckMakefileHeader validates that the Makefile starts with the Epitech
+// header:
+//
+//	##
+//	## EPITECH PROJECT, 2024
+//	## project name
+//	## File description:
+//	## description
+//	##
+func CheckMakefileHeader(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	lines := analysis.Lines
+	valid := len(lines) >= 6 &&
+		strings.TrimSpace(lines[0]) == "##" &&
+		epitechYear.MatchString(strings.TrimSpace(lines[1])) &&
+		strings.HasPrefix(lines[2], "## ") &&
+		strings.TrimSpace(lines[3]) == "## File description:" &&
+		strings.HasPrefix(lines[4], "## ") &&
+		strings.TrimSpace(lines[5]) == "##"
+	if valid {
+		return nil
+	}
+
+	return []types.Violation{{
+		Rule:        "C-MK4",
+		Message:     "Missing Epitech header",
+		Line:        1,
+		Severity:    "minor",
+		Description: "The Makefile must start with the Epitech header (## EPITECH PROJECT, year / name / File description:)",
+	}}
+}
+
+// compileCommand matches a recipe line invoking the compiler or linker
+var compileCommand = regexp.MustCompile(`(^|[\s@;])(\$[({](CC|LD|AR)[)}]|gcc|cc|clang|ld|ar)\s`)
+
+// CheckMakefileRelink validates that running make twice does not rebuild:
+// $(NAME) must not depend on a .PHONY target and 'all' must not build the
+// program itself
+func CheckMakefileRelink(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	mk := analysis.Makefile()
+
+	relink := func(line int, description string) {
+		violations = append(violations, types.Violation{
+			Rule:        "C-MK5",
+			Message:     "Makefile relinks",
+			Line:        line,
+			Severity:    "major",
+			Description: description,
+		})
+	}
+
+	if rule := nameTarget(mk); rule != nil {
+		for _, p := range rule.Prereqs {
+			if mk.Phony[p] {
+				relink(rule.Line, fmt.Sprintf("$(NAME) depends on the .PHONY target '%s' and is rebuilt every time", p))
+			}
+		}
+	}
+	if rule := mk.Target("all"); rule != nil {
+		for _, cmd := range rule.Recipe {
+			if compileCommand.MatchString(" " + cmd + " ") {
+				relink(rule.Line, "'all' builds the program itself, depend on $(NAME) instead")
+				break
+			}
+		}
+	}
+	return violations
+}
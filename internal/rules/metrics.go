@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"fmt"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// ComputeMetrics measures every function defined in the file
+func ComputeMetrics(analysis *types.FileAnalysis) []types.FunctionMetrics {
+	unit := analysis.Unit()
+	metrics := make([]types.FunctionMetrics, 0, len(unit.Functions))
+
+	for _, fn := range unit.Functions {
+		statements := 0
+		fn.Walk(func(s *parser.Statement) {
+			switch s.Kind {
+			case parser.StmtBlock, parser.StmtEmpty, parser.StmtCase, parser.StmtLabel:
+			default:
+				statements++
+			}
+		})
+
+		metrics = append(metrics, types.FunctionMetrics{
+			Name:       fn.Name,
+			Line:       fn.Line,
+			Complexity: complexity(unit, fn),
+			Nesting:    nesting(fn.Body, 0),
+			Statements: statements,
+			Params:     len(fn.Params),
+			Lines:      fn.EndLine - fn.Line + 1,
+		})
+	}
+	return metrics
+}
+
+// complexity returns the cyclomatic complexity of a function: one plus
+// its decision points (conditions, loops, cases and short-circuit
+// operators)
+func complexity(unit *parser.Unit, fn *parser.Function) int {
+	n := 1
+	for i := fn.Open + 1; i < fn.Close; i++ {
+		t := unit.Tokens[i]
+		if t.Kind != parser.Keyword && t.Kind != parser.Punct {
+			continue
+		}
+		switch t.Text {
+		case "if", "for", "while", "case", "&&", "||", "?":
+			n++
+		}
+	}
+	return n
+}
+
+// isControl reports whether a statement controls a nested body
+func isControl(kind parser.StmtKind) bool {
+	switch kind {
+	case parser.StmtIf, parser.StmtFor, parser.StmtWhile, parser.StmtDo, parser.StmtSwitch:
+		return true
+	}
+	return false
+}
+
+// nesting returns the deepest control statement nesting of a statement
+// list found at the given depth. An "else if" stays at the level of its if.
+func nesting(stmts []*parser.Statement, depth int) int {
+	deepest := depth
+	for _, s := range stmts {
+		d := depth
+		if isControl(s.Kind) {
+			d++
+		}
+		deepest = max(deepest, d, nesting(s.Body, d))
+		if s.Else != nil {
+			if s.Else.Kind == parser.StmtIf {
+				deepest = max(deepest, nesting([]*parser.Statement{s.Else}, depth))
+			} else {
+				deepest = max(deepest, nesting([]*parser.Statement{s.Else}, d))
+			}
+		}
+	}
+	return deepest
+}
+
+// CheckFunctionMetrics validates the complexity, nesting and statement
+// count of functions against the configured thresholds
+func CheckFunctionMetrics(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	cfg := analysis.Settings()
+
+	for _, m := range ComputeMetrics(analysis) {
+		checks := []struct {
+			what  string
+			value int
+			limit int
+		}{
+			{"cyclomatic complexity", m.Complexity, cfg.MaxComplexity},
+			{"nesting depth", m.Nesting, cfg.MaxNesting},
+			{"statement count", m.Statements, cfg.MaxStatements},
+		}
+		for _, c := range checks {
+			if c.limit <= 0 || c.value <= c.limit {
+				continue
+			}
+			violations = append(violations, types.Violation{
+				Rule:        "C-F7",
+				Message:     "Function too complex",
+				Line:        m.Line,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Function '%s' has a %s of %d (max %d)", m.Name, c.what, c.value, c.limit),
+			})
+		}
+	}
+	return violations
+}
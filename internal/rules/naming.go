@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// CheckIdentifierNames validates the names of variables, parameters,
+// globals, typedefs, tags and enumeration constants against the naming
+// patterns of the configuration
+func CheckIdentifierNames(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+	naming := analysis.Settings().Naming
+	seen := make(map[string]bool)
+
+	check := func(kind, name string, tok int) {
+		// Tags, typedefs and globals are named once per file, every use of
+		// a tag being parsed as its declaration; locals are distinct
+		// declarations in each scope
+		key := kind + " " + name
+		if kind == "variable" || kind == "parameter" {
+			key = fmt.Sprintf("%s %d", kind, tok)
+		}
+		pattern := naming[kind]
+		if name == "" || tok < 0 || seen[key] || types.MatchesNamingPattern(name, pattern) {
+			return
+		}
+		seen[key] = true
+		label := strings.ReplaceAll(kind, "_", " ")
+		violations = append(violations, types.Violation{
+			Rule:        "C-V2",
+			Message:     "Invalid " + label + " name",
+			Line:        unit.Tokens[tok].Line,
+			Severity:    "major",
+			Description: fmt.Sprintf("%s '%s' must match %s", strings.ToUpper(label[:1])+label[1:], name, pattern),
+		})
+	}
+
+	checkDecl := func(decl *parser.Declaration, scope string) {
+		if decl.Tag != "" {
+			check(decl.TagKind, decl.Tag, decl.TagTok)
+		}
+		for _, tok := range decl.Enumerators {
+			check("enum_constant", unit.Tokens[tok].Text, tok)
+		}
+		for _, d := range decl.Declarators {
+			switch {
+			case decl.Typedef:
+				check("typedef", d.Name, d.NameTok)
+			case d.Function && scope == "global":
+				// prototypes are covered by the function name rule
+			default:
+				check(scope, d.Name, d.NameTok)
+			}
+		}
+	}
+
+	for _, decl := range unit.Decls {
+		checkDecl(decl, "global")
+	}
+	for _, fn := range unit.Functions {
+		for _, p := range fn.Params {
+			check("parameter", p.Name, p.NameTok)
+		}
+		fn.Walk(func(s *parser.Statement) {
+			if s.Decl != nil {
+				checkDecl(s.Decl, "variable")
+			}
+		})
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Line < violations[j].Line })
+	return violations
+}
@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// numberValue returns the value of a numeric literal, suffixes ignored
+func numberValue(text string) (float64, bool) {
+	text = strings.ToLower(text)
+	hex := strings.HasPrefix(text, "0x")
+	text = strings.TrimRight(text, "ul")
+	if !hex {
+		text = strings.TrimSuffix(text, "f")
+	}
+	if v, err := strconv.ParseInt(text, 0, 64); err == nil {
+		return float64(v), true
+	}
+	if v, err := strconv.ParseUint(text, 0, 64); err == nil {
+		return float64(v), true
+	}
+	if v, err := strconv.ParseFloat(text, 64); err == nil {
+		return v, true
+	}
+	return 0, false
+}
+
+// isOperand reports whether a token ends an operand, making a following
+// '-' a binary operator
+func isOperand(t parser.Token) bool {
+	switch t.Kind {
+	case parser.Ident, parser.Number, parser.String, parser.Char:
+		return true
+	}
+	return t.Is(")") || t.Is("]")
+}
+
+// excludedNumbers returns the tokens whose numeric literals are named
+// constants by construction: enum bodies and const file-scope declarations
+func excludedNumbers(unit *parser.Unit) [][2]int {
+	var ranges [][2]int
+	for _, decl := range unit.Decls {
+		if decl.Const {
+			ranges = append(ranges, [2]int{decl.Start, decl.End})
+		}
+	}
+	for _, decl := range unit.AllDeclarations() {
+		for i := decl.Start; i <= decl.TypeEnd; i++ {
+			if unit.Tokens[i].Is("enum") {
+				ranges = append(ranges, [2]int{decl.Start, decl.TypeEnd})
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// CheckMagicNumbers validates that numeric literals other than 0, 1, -1
+// and the configured exceptions are given a name through #define, an enum
+// or a const global
+func CheckMagicNumbers(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+	cfg := analysis.Settings()
+	tabWidth := cfg.TabWidth
+
+	allowed := map[float64]bool{0: true, 1: true, -1: true}
+	for _, n := range cfg.AllowedNumbers {
+		negative := strings.HasPrefix(n, "-")
+		if v, ok := numberValue(strings.TrimPrefix(n, "-")); ok {
+			if negative {
+				v = -v
+			}
+			allowed[v] = true
+		}
+	}
+
+	excluded := excludedNumbers(unit)
+	for i, t := range unit.Tokens {
+		if t.Kind != parser.Number {
+			continue
+		}
+		skip := false
+		for _, r := range excluded {
+			if i >= r[0] && i <= r[1] {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+
+		literal, start := t.Text, t
+		if prev := unit.Prev(i - 1); prev >= 0 && unit.Tokens[prev].Is("-") {
+			if before := unit.Prev(prev - 1); before < 0 || !isOperand(unit.Tokens[before]) {
+				literal, start = "-"+t.Text, unit.Tokens[prev]
+			}
+		}
+
+		value, ok := numberValue(t.Text)
+		if !ok {
+			continue
+		}
+		if literal[0] == '-' {
+			value = -value
+		}
+		if allowed[value] {
+			continue
+		}
+
+		column := start.Col
+		if start.Line-1 < len(analysis.Lines) {
+			column = types.Column(analysis.Lines[start.Line-1], start.Col-1, tabWidth)
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-M1",
+			Message:     "Magic number",
+			Line:        start.Line,
+			Column:      column,
+			Severity:    "minor",
+			Description: fmt.Sprintf("Literal %s at column %d should be a named constant (#define, enum or const global)", literal, column),
+		})
+	}
+	return violations
+}
@@ -0,0 +1,132 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// EmptyParamList is a "()" parameter list of a function prototype or
+// function pointer declaration
+type EmptyParamList struct {
+	Name  string
+	Open  int // index of the '(' token
+	Close int // index of the ')' token
+}
+
+// PrototypeEmptyParams returns the empty parameter lists of the function
+// declarators of the unit. Function definitions are described by
+// FunctionInfo.EmptyParams.
+func PrototypeEmptyParams(unit *parser.Unit) []EmptyParamList {
+	var lists []EmptyParamList
+	for _, decl := range unit.AllDeclarations() {
+		for _, d := range decl.Declarators {
+			if d.Function && unit.Next(d.ParamOpen+1) == d.ParamClose {
+				lists = append(lists, EmptyParamList{Name: d.Name, Open: d.ParamOpen, Close: d.ParamClose})
+			}
+		}
+	}
+	return lists
+}
+
+// CheckExplicitVoid validates that functions without parameters are
+// declared with "(void)" rather than "()"
+func CheckExplicitVoid(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+
+	add := func(name string, line int) {
+		violations = append(violations, types.Violation{
+			Rule:        "C-F5",
+			Message:     "Missing void",
+			Line:        line,
+			Severity:    "major",
+			Description: fmt.Sprintf("Function '%s' takes no parameter, write '%s(void)'", name, name),
+		})
+	}
+
+	for _, fn := range analysis.Functions {
+		if fn.EmptyParams {
+			add(fn.Name, fn.StartLine)
+		}
+	}
+	for _, list := range PrototypeEmptyParams(unit) {
+		add(list.Name, unit.Tokens[list.Open].Line)
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Line < violations[j].Line })
+	return violations
+}
+
+// structTypedefs returns the typedef names of the structures and unions
+// declared in the unit
+func structTypedefs(unit *parser.Unit) map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range unit.AllDeclarations() {
+		if !decl.Typedef || !isRecordType(decl.Type) {
+			continue
+		}
+		for _, d := range decl.Declarators {
+			if d.Pointers == 0 && !d.Function && !d.Array {
+				names[d.Name] = true
+			}
+		}
+	}
+	return names
+}
+
+// isRecordType reports whether a base type names a structure or a union
+func isRecordType(typ string) bool {
+	return typ == "struct" || typ == "union" ||
+		strings.HasPrefix(typ, "struct ") || strings.HasPrefix(typ, "union ")
+}
+
+// CheckStructByValue validates that structures and unions are passed to
+// functions by pointer
+func CheckStructByValue(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+	typedefs := structTypedefs(unit)
+
+	check := func(function string, p types.ParamInfo) {
+		if p.Pointers > 0 || p.Array || (!isRecordType(p.Type) && !typedefs[p.Type]) {
+			return
+		}
+		name := p.Name
+		if name == "" {
+			name = p.Type
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-F6",
+			Message:     "Structure passed by value",
+			Line:        p.Line,
+			Severity:    "major",
+			Description: fmt.Sprintf("Parameter '%s' of '%s' has type '%s', pass a pointer instead", name, function, p.Type),
+		})
+	}
+
+	for _, fn := range analysis.Functions {
+		for _, p := range fn.Params {
+			check(fn.Name, p)
+		}
+	}
+	for _, decl := range unit.AllDeclarations() {
+		for _, d := range decl.Declarators {
+			for _, p := range d.Params {
+				check(d.Name, types.ParamInfo{
+					Type:     p.Type,
+					Name:     p.Name,
+					Pointers: p.Pointers,
+					Array:    p.Array,
+					Line:     unit.Tokens[p.Start].Line,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Line < violations[j].Line })
+	return violations
+}
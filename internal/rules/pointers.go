@@ -0,0 +1,178 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// PointerSpan is a run of '*' tokens belonging to a declarator, a
+// parameter, a cast or the return type of a function definition, along
+// with the tokens surrounding it
+type PointerSpan struct {
+	Prev  int // token before the first asterisk (the type or a comma)
+	First int
+	Last  int
+	Next  int // token after the last asterisk (the name or a parenthesis)
+}
+
+// Misplaced reports whether the asterisks are not written as "type *name":
+// one space before, no space between or after them
+func (s PointerSpan) Misplaced(unit *parser.Unit) bool {
+	toks := unit.Tokens
+	if adjacent(toks[s.Prev], toks[s.First]) {
+		return true
+	}
+	for i := s.First; i < s.Last; i++ {
+		if !adjacent(toks[i], toks[i+1]) {
+			return true
+		}
+	}
+	return !adjacent(toks[s.Last], toks[s.Next])
+}
+
+// Replacement returns the text that should stand between the end of Prev
+// and the start of Next
+func (s PointerSpan) Replacement() string {
+	return " " + strings.Repeat("*", s.Last-s.First+1)
+}
+
+// adjacent reports whether b starts right where a ends
+func adjacent(a, b parser.Token) bool {
+	return a.Offset+len(a.Text) == b.Offset
+}
+
+// FindPointerSpans locates the pointer asterisks of declarations,
+// parameters, casts and function return types. Multiplications are never
+// returned since only declaration contexts are examined.
+func FindPointerSpans(unit *parser.Unit) []PointerSpan {
+	var spans []PointerSpan
+	toks := unit.Tokens
+
+	// add records the run of asterisks ending right before next
+	add := func(next int) {
+		last := next - 1
+		if last < 0 || !toks[last].Is("*") {
+			return
+		}
+		first := last
+		for first > 0 && toks[first-1].Is("*") {
+			first--
+		}
+		prev := first - 1
+		if prev < 0 || (toks[prev].Kind != parser.Ident && toks[prev].Kind != parser.Keyword && !toks[prev].Is(",")) {
+			return
+		}
+		if toks[prev].Line != toks[next].Line {
+			return
+		}
+		spans = append(spans, PointerSpan{Prev: prev, First: first, Last: last, Next: next})
+	}
+
+	addParams := func(params []*parser.Param) {
+		for _, p := range params {
+			if p.NameTok > 0 {
+				add(p.NameTok)
+			} else if p.Pointers > 0 {
+				add(p.End + 1)
+			}
+		}
+	}
+
+	for _, decl := range unit.AllDeclarations() {
+		for _, d := range decl.Declarators {
+			if d.Pointers > 0 && d.NameTok > 0 {
+				add(d.NameTok)
+			}
+			addParams(d.Params)
+		}
+	}
+
+	for _, fn := range unit.Functions {
+		add(fn.NameTok)
+		addParams(fn.Params)
+	}
+
+	// Casts and sizeof operands: "(" type-words "*"... ")"
+	for i := 0; i < len(toks); i++ {
+		if !toks[i].Is("(") {
+			continue
+		}
+		j := i + 1
+		for j < len(toks) && (toks[j].Kind == parser.Ident || (toks[j].Kind == parser.Keyword && isTypeWord(toks[j].Text))) {
+			j++
+		}
+		if j == i+1 || j >= len(toks) || !toks[j].Is("*") {
+			continue
+		}
+		for j < len(toks) && toks[j].Is("*") {
+			j++
+		}
+		if j < len(toks) && toks[j].Is(")") {
+			add(j)
+		}
+	}
+
+	sort.Slice(spans, func(a, b int) bool { return spans[a].First < spans[b].First })
+	uniq := spans[:0]
+	for i, s := range spans {
+		if i == 0 || s.First != spans[i-1].First {
+			uniq = append(uniq, s)
+		}
+	}
+	return uniq
+}
+
+// isTypeWord reports whether a keyword may appear in a type name
+func isTypeWord(kw string) bool {
+	switch kw {
+	case "char", "const", "double", "enum", "float", "int", "long", "short",
+		"signed", "struct", "union", "unsigned", "void", "volatile", "_Bool":
+		return true
+	}
+	return false
+}
+
+// CheckPointerDeclarations validates that pointer asterisks are attached
+// to the identifier and that pointers are not declared along with
+// non-pointer variables
+func CheckPointerDeclarations(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	unit := analysis.Unit()
+
+	for _, span := range FindPointerSpans(unit) {
+		if span.Misplaced(unit) {
+			violations = append(violations, types.Violation{
+				Rule:        "C-V3",
+				Message:     "Misplaced pointer asterisk",
+				Line:        unit.Tokens[span.First].Line,
+				Severity:    "minor",
+				Description: fmt.Sprintf("Write '%s%s%s', with the asterisk attached to what follows", unit.Tokens[span.Prev].Text, span.Replacement(), unit.Tokens[span.Next].Text),
+			})
+		}
+	}
+
+	for _, decl := range unit.AllDeclarations() {
+		pointers := 0
+		for _, d := range decl.Declarators {
+			if d.Pointers > 0 {
+				pointers++
+			}
+		}
+		if pointers > 0 && pointers < len(decl.Declarators) {
+			violations = append(violations, types.Violation{
+				Rule:        "C-V3",
+				Message:     "Mixed pointer declaration",
+				Line:        decl.Line,
+				Severity:    "minor",
+				Description: "Do not declare pointer and non-pointer variables on the same line",
+			})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Line < violations[j].Line })
+	return violations
+}
@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"epicstyle/internal/types"
+)
+
+// unwantedExtensions are the build products that must not be delivered
+var unwantedExtensions = map[string]string{
+	".o":   "object file",
+	".a":   "static library",
+	".so":  "shared library",
+	".gch": "precompiled header",
+}
+
+// elfMagic starts every ELF executable, object and library
+var elfMagic = []byte{0x7F, 'E', 'L', 'F'}
+
+// isELF reports whether the file starts with the ELF magic bytes
+func isELF(filename string) bool {
+	file, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(elfMagic))
+	n, _ := file.Read(header)
+	return n == len(elfMagic) && bytes.Equal(header, elfMagic)
+}
+
+// unwantedKind describes why a file should not be in the repository, or
+// returns "" for a legitimate file
+func unwantedKind(root string, entry types.TreeEntry) string {
+	base := path.Base(entry.Path)
+	ext := path.Ext(base)
+	switch {
+	case unwantedExtensions[ext] != "":
+		return unwantedExtensions[ext]
+	case strings.HasSuffix(base, "~"):
+		return "editor backup file"
+	case len(base) > 1 && strings.HasPrefix(base, "#") && strings.HasSuffix(base, "#"):
+		return "editor autosave file"
+	case ext == ".c" || ext == ".h":
+		return ""
+	case isELF(filepath.Join(root, filepath.FromSlash(entry.Path))):
+		return "compiled binary"
+	}
+	return ""
+}
+
+// CheckUnwantedFiles reports the build products and temporary files of
+// the analyzed tree
+func CheckUnwantedFiles(project *types.ProjectAnalysis) []types.Violation {
+	var violations []types.Violation
+	for _, entry := range project.Tree {
+		if entry.Dir {
+			continue
+		}
+		if kind := unwantedKind(project.Root, entry); kind != "" {
+			violations = append(violations, types.Violation{
+				Rule:        "C-O3",
+				Message:     "Unwanted file",
+				File:        entry.Path,
+				Severity:    "major",
+				Description: fmt.Sprintf("'%s' is a %s and must not be delivered", entry.Path, kind),
+			})
+		}
+	}
+	return violations
+}
+
+// CheckDirectoryNames validates that directory names are in snake_case
+func CheckDirectoryNames(project *types.ProjectAnalysis) []types.Violation {
+	var violations []types.Violation
+	for _, entry := range project.Tree {
+		if !entry.Dir || types.IsSnakeCase(path.Base(entry.Path)) {
+			continue
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-O4",
+			Message:     "Invalid directory name",
+			File:        entry.Path,
+			Severity:    "minor",
+			Description: fmt.Sprintf("Directory '%s' must be in snake_case", path.Base(entry.Path)),
+		})
+	}
+	return violations
+}
+
+// CheckSourcePlacement validates that .c files are placed in one of the
+// configured source directories
+func CheckSourcePlacement(project *types.ProjectAnalysis) []types.Violation {
+	var violations []types.Violation
+	dirs := project.Settings().SourceDirs
+	if len(dirs) == 0 {
+		return violations
+	}
+
+	for _, entry := range project.Tree {
+		if entry.Dir || path.Ext(entry.Path) != ".c" || inSourceDir(entry.Path, dirs) {
+			continue
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-O5",
+			Message:     "Misplaced source file",
+			File:        entry.Path,
+			Severity:    "minor",
+			Description: fmt.Sprintf("'%s' is outside the source directories (%s)", entry.Path, strings.Join(dirs, ", ")),
+		})
+	}
+	return violations
+}
+
+// inSourceDir reports whether a relative path lies below one of dirs
+func inSourceDir(file string, dirs []string) bool {
+	for _, dir := range dirs {
+		dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/")
+		if dir == "." || dir == "" {
+			if !strings.Contains(file, "/") {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -11,14 +11,15 @@ import (
 // CheckLineLength validates that no line exceeds 80 characters
 func CheckLineLength(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
 	var violations []types.Violation
+	cfg := analysis.Settings()
 	for i, line := range analysis.Lines {
-		if len(line) > 80 {
+		if width := types.DisplayWidth(line, cfg.TabWidth); width > cfg.MaxLineLength {
 			violations = append(violations, types.Violation{
 				Rule:        "C-L1",
 				Message:     "Line too long",
 				Line:        i + 1,
 				Severity:    "major",
-				Description: fmt.Sprintf("Line contains %d characters (max 80)", len(line)),
+				Description: fmt.Sprintf("Line spans %d columns (max %d)", width, cfg.MaxLineLength),
 			})
 		}
 	}
@@ -68,11 +69,22 @@ func CheckEmptyLines(analysis *types.FileAnalysis, filename string, lineNum int)
 	return violations
 }
 
-// checkIndentation validates that only TABs are used for indentation
+// checkIndentation validates that only TABs are used for indentation, or
+// only spaces under the official profile
 func CheckIndentation(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
 	var violations []types.Violation
+	official := analysis.Settings().Profile == types.ProfileOfficial
 	for i, line := range analysis.Lines {
-		if len(line) > 0 && line[0] == ' ' {
+		switch {
+		case official && strings.ContainsRune(leadingWhitespace(line), '\t'):
+			violations = append(violations, types.Violation{
+				Rule:        "C-L3",
+				Message:     "Tab indentation",
+				Line:        i + 1,
+				Severity:    "major",
+				Description: "Use 4 spaces for indentation, not tabs",
+			})
+		case !official && len(line) > 0 && line[0] == ' ':
 			violations = append(violations, types.Violation{
 				Rule:        "C-L3",
 				Message:     "Space indentation",
@@ -85,23 +97,18 @@ func CheckIndentation(analysis *types.FileAnalysis, filename string, lineNum int
 	return violations
 }
 
-// checkVariableDeclaration ensures only one variable per line
+// CheckVariableDeclaration ensures only one variable per line
 func CheckVariableDeclaration(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
 	var violations []types.Violation
-	for i, line := range analysis.Lines {
-		trimmed := strings.TrimSpace(line)
-		// Simple check for multiple variable declarations
-		if strings.Contains(trimmed, "int ") || strings.Contains(trimmed, "char ") ||
-			strings.Contains(trimmed, "float ") || strings.Contains(trimmed, "double ") {
-			if strings.Count(trimmed, ",") > 0 && !strings.Contains(trimmed, "for") {
-				violations = append(violations, types.Violation{
-					Rule:        "C-L4",
-					Message:     "Multiple variable declaration",
-					Line:        i + 1,
-					Severity:    "major",
-					Description: "Declare only one variable per line",
-				})
-			}
+	for _, decl := range analysis.Unit().AllDeclarations() {
+		if len(decl.Declarators) > 1 {
+			violations = append(violations, types.Violation{
+				Rule:        "C-L4",
+				Message:     "Multiple variable declaration",
+				Line:        decl.Line,
+				Severity:    "major",
+				Description: fmt.Sprintf("Declare only one variable per line (%d declared)", len(decl.Declarators)),
+			})
 		}
 	}
 	return violations
@@ -131,31 +138,40 @@ func CheckFilename(analysis *types.FileAnalysis, filename string, lineNum int) [
 	return violations
 }
 
-// checkFunctionCount ensures max 3 functions per file (excluding main)
+// CheckFunctionCount limits the number of function definitions per file,
+// and separately the number of non-static ones. The main entry point is
+// not counted.
 func CheckFunctionCount(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
 	var violations []types.Violation
-	funcCount := 0
+	cfg := analysis.Settings()
+	total, exported := 0, 0
 
-	for _, line := range analysis.Lines {
-		trimmed := strings.TrimSpace(line)
-		// Simple function detection
-		if strings.Contains(trimmed, "(") && strings.Contains(trimmed, ")") &&
-			strings.Contains(trimmed, "{") && !strings.HasPrefix(trimmed, "//") &&
-			!strings.HasPrefix(trimmed, "/*") && !strings.Contains(trimmed, "if") &&
-			!strings.Contains(trimmed, "while") && !strings.Contains(trimmed, "for") {
-			if !strings.Contains(trimmed, "main") {
-				funcCount++
-			}
+	for _, fn := range analysis.Unit().Functions {
+		if fn.Name == "main" && !fn.Static {
+			continue
+		}
+		total++
+		if !fn.Static {
+			exported++
 		}
 	}
 
-	if funcCount > 3 {
+	if total > cfg.MaxFunctions {
 		violations = append(violations, types.Violation{
 			Rule:        "C-O2",
 			Message:     "Too many functions",
 			Line:        0,
 			Severity:    "major",
-			Description: fmt.Sprintf("File contains %d functions (max 3 excluding main)", funcCount),
+			Description: fmt.Sprintf("File contains %d functions (max %d excluding main)", total, cfg.MaxFunctions),
+		})
+	}
+	if exported > cfg.MaxExportedFunctions {
+		violations = append(violations, types.Violation{
+			Rule:        "C-O2",
+			Message:     "Too many non-static functions",
+			Line:        0,
+			Severity:    "major",
+			Description: fmt.Sprintf("File contains %d non-static functions (max %d excluding main)", exported, cfg.MaxExportedFunctions),
 		})
 	}
 	return violations
@@ -178,30 +194,6 @@ func CheckFunctionNames(analysis *types.FileAnalysis, filename string, lineNum i
 	return violations
 }
 
-// checkMacroNames validates macro names are in SCREAMING_SNAKE_CASE
-func CheckMacroNames(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
-	var violations []types.Violation
-	for i, line := range analysis.Lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "#define ") {
-			parts := strings.Fields(trimmed)
-			if len(parts) >= 2 {
-				macroName := parts[1]
-				if !types.IsScreamingSnakeCase(macroName) {
-					violations = append(violations, types.Violation{
-						Rule:        "C-F2",
-						Message:     "Invalid macro name",
-						Line:        i + 1,
-						Severity:    "major",
-						Description: fmt.Sprintf("Macro '%s' must be in SCREAMING_SNAKE_CASE", macroName),
-					})
-				}
-			}
-		}
-	}
-	return violations
-}
-
 // checkFunctionLength validates functions don't exceed 25 lines
 func CheckFunctionLength(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
 	var violations []types.Violation
@@ -223,16 +215,14 @@ func CheckFunctionLength(analysis *types.FileAnalysis, filename string, lineNum
 // checkCommentFormat validates use of /* */ comments only
 func CheckCommentFormat(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
 	var violations []types.Violation
-	for i, line := range analysis.Lines {
-		if strings.Contains(line, "//") {
-			violations = append(violations, types.Violation{
-				Rule:        "C-C1",
-				Message:     "Invalid comment format",
-				Line:        i + 1,
-				Severity:    "minor",
-				Description: "Use /* */ comments only, not // comments",
-			})
-		}
+	for _, c := range LineComments(analysis.Unit()) {
+		violations = append(violations, types.Violation{
+			Rule:        "C-C1",
+			Message:     "Invalid comment format",
+			Line:        c.Line + 1,
+			Severity:    "minor",
+			Description: "Use /* */ comments only, not // comments",
+		})
 	}
 	return violations
 }
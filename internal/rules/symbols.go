@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"fmt"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// DefinedSymbols returns the names of the functions and macros the file
+// defines
+func DefinedSymbols(unit *parser.Unit) []string {
+	var names []string
+	for _, fn := range unit.Functions {
+		names = append(names, fn.Name)
+	}
+	for _, t := range unit.Tokens {
+		if t.Kind != parser.Directive {
+			continue
+		}
+		if d := parser.ParseDirective(t.Text); d.Name == "define" && d.MacroName() != "" {
+			names = append(names, d.MacroName())
+		}
+	}
+	return names
+}
+
+// ExternalSymbols lists the system headers included by the file and the
+// functions it calls that are defined neither in the project nor as local
+// variables (function pointers)
+func ExternalSymbols(analysis *types.FileAnalysis) []types.SymbolRef {
+	var refs []types.SymbolRef
+	unit := analysis.Unit()
+
+	known := make(map[string]bool)
+	if analysis.ProjectSymbols != nil {
+		for name := range analysis.ProjectSymbols {
+			known[name] = true
+		}
+	}
+	for _, name := range DefinedSymbols(unit) {
+		known[name] = true
+	}
+	for _, decl := range unit.AllDeclarations() {
+		for _, d := range decl.Declarators {
+			if !d.Function {
+				known[d.Name] = true
+			}
+		}
+	}
+	for _, fn := range unit.Functions {
+		for _, p := range fn.Params {
+			known[p.Name] = true
+		}
+	}
+
+	for _, t := range unit.Tokens {
+		if t.Kind != parser.Directive {
+			continue
+		}
+		d := parser.ParseDirective(t.Text)
+		if d.Name != "include" {
+			continue
+		}
+		if header, system := d.Header(); system {
+			refs = append(refs, types.SymbolRef{Name: header, Kind: "header", Line: t.Line})
+		}
+	}
+
+	for _, i := range unit.Calls() {
+		t := unit.Tokens[i]
+		if !known[t.Text] {
+			refs = append(refs, types.SymbolRef{Name: t.Text, Kind: "function", Line: t.Line})
+		}
+	}
+
+	return refs
+}
+
+// CheckForbiddenFunctions validates external function calls and system
+// header inclusions against the allow and deny lists of the configuration
+func CheckForbiddenFunctions(analysis *types.FileAnalysis, filename string, lineNum int) []types.Violation {
+	var violations []types.Violation
+	cfg := analysis.Settings()
+
+	for _, ref := range ExternalSymbols(analysis) {
+		if !cfg.IsForbidden(ref.Kind, ref.Name) {
+			continue
+		}
+		message := "Forbidden function"
+		description := fmt.Sprintf("Function '%s' is not allowed in this project", ref.Name)
+		if ref.Kind == "header" {
+			message = "Forbidden header"
+			description = fmt.Sprintf("Header <%s> is not allowed in this project", ref.Name)
+		}
+		violations = append(violations, types.Violation{
+			Rule:        "C-B1",
+			Message:     message,
+			Line:        ref.Line,
+			Severity:    "major",
+			Description: description,
+		})
+	}
+	return violations
+}
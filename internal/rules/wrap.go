@@ -0,0 +1,291 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"epicstyle/internal/parser"
+	"epicstyle/internal/types"
+)
+
+// binaryOperators are the operators a long line may be split before
+var binaryOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true,
+	"<<": true, ">>": true, "<": true, ">": true, "<=": true, ">=": true,
+	"==": true, "!=": true, "&": true, "^": true, "|": true, "&&": true, "||": true,
+}
+
+// breakPoint is a byte offset of a line where it may be split. Splitting
+// inside a string literal closes it at the end of the line and opens it
+// again on the next one.
+type breakPoint struct {
+	Pos   int
+	Quote bool
+}
+
+// FixLineLength splits the lines spanning more columns than allowed
+// (C-L1) at safe points: after the commas of argument lists, before binary
+// operators, between or inside string literals and between the words of
+// comments. Continuation lines are indented one level deeper. Lines with
+// no safe split, such as directives, are reported as left unchanged.
+func FixLineLength(analysis *types.FileAnalysis) []types.Fix {
+	cfg := analysis.Settings()
+	w := lineWrapper{maxLength: cfg.MaxLineLength, tabWidth: cfg.TabWidth, indent: cfg.IndentUnit()}
+	lines := analysis.Lines
+
+	var long []int
+	for i, line := range lines {
+		if w.tooLong(line) {
+			long = append(long, i)
+		}
+	}
+	if len(long) == 0 {
+		return nil
+	}
+	starts := lineStarts(analysis)
+
+	// Tokens overlapping each line
+	tokens := make([][]parser.Token, len(lines))
+	for _, t := range analysis.Unit().Tokens {
+		for l := t.Line - 1; l < t.EndLine && l < len(lines); l++ {
+			tokens[l] = append(tokens[l], t)
+		}
+	}
+
+	// Level of the continuation lines of each line, from its first token
+	// of code
+	levels := make(map[int]int)
+	walkBlocks(analysis.Unit(), func(_ int, t parser.Token, state *blockState) {
+		if _, ok := levels[t.Line-1]; !ok && t.Kind != parser.Comment && t.Kind != parser.Directive {
+			levels[t.Line-1] = state.continuationLevel(t)
+		}
+	})
+
+	var fixes []types.Fix
+	for _, i := range long {
+		width := types.DisplayWidth(lines[i], cfg.TabWidth)
+		w.cont = strings.Repeat(w.indent, levels[i])
+		split, reason := w.wrapLine(lines[i], i+1, tokens[i])
+		if split == nil {
+			fixes = append(fixes, types.Fix{
+				Rule:        "C-L1",
+				Description: fmt.Sprintf("Left line of %d columns unchanged: %s", width, reason),
+				Line:        i + 1,
+			})
+			continue
+		}
+		fixes = append(fixes, types.Fix{
+			Rule:        "C-L1",
+			Description: fmt.Sprintf("Split line of %d columns into %d lines", width, len(split)),
+			Line:        i + 1,
+			Edits:       []types.Edit{replaceLines(analysis, starts, i, i, split)},
+		})
+	}
+	return fixes
+}
+
+// lineWrapper splits lines to fit the configured length
+type lineWrapper struct {
+	maxLength int
+	tabWidth  int
+	indent    string // one indentation level
+	cont      string // indentation of the continuation lines of the line split
+}
+
+// wrapLine splits a long line given the tokens overlapping it, or returns
+// the reason it cannot be split safely
+func (w lineWrapper) wrapLine(line string, lineNum int, toks []parser.Token) ([]string, string) {
+	if len(toks) == 0 {
+		return nil, "no safe split point"
+	}
+	for _, t := range toks {
+		if t.Kind == parser.Directive {
+			return nil, "preprocessor directive"
+		}
+	}
+	if strings.HasSuffix(strings.TrimRight(line, " \t"), "\\") {
+		return nil, "continued by a backslash"
+	}
+
+	// Inside a multi-line comment only the lines between its delimiters
+	// are made of words alone
+	first, last := toks[0], toks[len(toks)-1]
+	if len(toks) == 1 && first.Kind == parser.Comment && first.Line != first.EndLine {
+		if first.Line == lineNum || first.EndLine == lineNum {
+			return nil, "delimiter of a multi-line comment"
+		}
+		return w.wrapCommentText(line)
+	}
+	if first.Line != lineNum || last.EndLine != lineNum {
+		return nil, "part of a multi-line token"
+	}
+
+	indent := leadingWhitespace(line)
+	if last.Kind != parser.Comment {
+		return w.wrapCode(line, toks)
+	}
+	if len(toks) == 1 {
+		return w.wrapComment(indent, last.Text)
+	}
+
+	// Move a trailing comment above its code, then split both if needed
+	code := strings.TrimRight(line[:last.Col-1], " \t")
+	comment := []string{indent + last.Text}
+	if w.tooLong(comment[0]) {
+		var reason string
+		if comment, reason = w.wrapComment(indent, last.Text); comment == nil {
+			return nil, reason
+		}
+	}
+	split := []string{code}
+	if w.tooLong(code) {
+		var reason string
+		if split, reason = w.wrapCode(code, toks[:len(toks)-1]); split == nil {
+			return nil, reason
+		}
+	}
+	return append(comment, split...), ""
+}
+
+// wrapCode splits a line of code at the rightmost break points keeping
+// each part within the limit
+func (w lineWrapper) wrapCode(line string, toks []parser.Token) ([]string, string) {
+	points := breakPoints(toks)
+	cont := w.cont
+
+	var split []string
+	prefix, start := "", 0
+	for w.tooLong(prefix + line[start:]) {
+		best := ""
+		var next breakPoint
+		for _, p := range points {
+			if p.Pos <= start || strings.TrimSpace(line[start:p.Pos]) == "" {
+				continue
+			}
+			part := prefix + strings.TrimRight(line[start:p.Pos], " \t")
+			if p.Quote {
+				part = prefix + line[start:p.Pos] + `"`
+			}
+			if w.tooLong(part) {
+				break
+			}
+			best, next = part, p
+		}
+		if best == "" {
+			return nil, "no safe split point"
+		}
+
+		split = append(split, best)
+		prefix, start = cont, next.Pos
+		if next.Quote {
+			prefix += `"`
+		}
+	}
+	return append(split, prefix+line[start:]), ""
+}
+
+// breakPoints returns the offsets, in increasing order, where a line made
+// of the given tokens may be split
+func breakPoints(toks []parser.Token) []breakPoint {
+	var points []breakPoint
+	depth := 0
+	for j, t := range toks {
+		col := t.Col - 1
+		switch t.Kind {
+		case parser.Punct:
+			switch {
+			case t.Text == "(" || t.Text == "[" || t.Text == "{":
+				depth++
+			case t.Text == ")" || t.Text == "]" || t.Text == "}":
+				depth--
+			case t.Text == "," && depth > 0 && j+1 < len(toks):
+				points = append(points, breakPoint{Pos: toks[j+1].Col - 1})
+			case binaryOperators[t.Text] && j > 0 && isOperand(toks[j-1]) && j+1 < len(toks):
+				points = append(points, breakPoint{Pos: col})
+			}
+		case parser.String:
+			if j > 0 && toks[j-1].Kind == parser.String {
+				points = append(points, breakPoint{Pos: col})
+			}
+			if j > 0 && toks[j-1].Kind == parser.Ident && toks[j-1].Col+len(toks[j-1].Text) == t.Col {
+				continue // prefixed literal, such as L"..."
+			}
+			if len(t.Text) < 2 || !strings.HasSuffix(t.Text, `"`) {
+				continue
+			}
+			for k := 1; k+1 < len(t.Text)-1; k++ {
+				if t.Text[k] == ' ' && t.Text[k-1] != '\\' {
+					points = append(points, breakPoint{Pos: col + k + 1, Quote: true})
+				}
+			}
+		}
+	}
+	return points
+}
+
+// wrapComment turns a long /* */ comment into a block whose lines hold
+// as many of its words as fit
+func (w lineWrapper) wrapComment(indent, comment string) ([]string, string) {
+	if !strings.HasPrefix(comment, "/*") {
+		return nil, "// comment"
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	words := strings.Fields(body)
+	if len(words) == 0 {
+		return nil, "no safe split point"
+	}
+	text := w.fillWords(indent+"** ", words)
+	if text == nil {
+		return nil, "word longer than a line"
+	}
+	split := append([]string{indent + "/*"}, text...)
+	return append(split, indent+"*/"), ""
+}
+
+// wrapCommentText splits a line inside a multi-line comment between its
+// words, repeating its indentation and leading stars
+func (w lineWrapper) wrapCommentText(line string) ([]string, string) {
+	p := len(leadingWhitespace(line))
+	for p < len(line) && line[p] == '*' {
+		p++
+	}
+	for p < len(line) && (line[p] == ' ' || line[p] == '\t') {
+		p++
+	}
+	split := w.fillWords(line[:p], strings.Fields(line[p:]))
+	if len(split) < 2 {
+		return nil, "no safe split point"
+	}
+	return split, ""
+}
+
+// fillWords lays words out on lines starting with prefix, as many per line
+// as fit. It returns nil when a word does not fit on its own line.
+func (w lineWrapper) fillWords(prefix string, words []string) []string {
+	var split []string
+	current := ""
+	for _, word := range words {
+		if current != "" && !w.tooLong(prefix+current+" "+word) {
+			current += " " + word
+			continue
+		}
+		if current != "" {
+			split = append(split, prefix+current)
+		}
+		if w.tooLong(prefix + word) {
+			return nil
+		}
+		current = word
+	}
+	return append(split, prefix+current)
+}
+
+// tooLong reports whether a line spans more columns than allowed
+func (w lineWrapper) tooLong(line string) bool {
+	return types.DisplayWidth(line, w.tabWidth) > w.maxLength
+}
+
+// leadingWhitespace returns the indentation of a line
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
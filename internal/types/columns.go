@@ -0,0 +1,123 @@
+package types
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges lists the East Asian wide and fullwidth code points, along
+// with the emoji blocks, that terminals display on two columns
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo initials
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x2329, 0x232A},   // angle brackets
+	{0x23E9, 0x23EC},   // media control symbols
+	{0x23F0, 0x23F0},   // alarm clock
+	{0x23F3, 0x23F3},   // hourglass with flowing sand
+	{0x25FD, 0x25FE},   // medium small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac signs
+	{0x267F, 0x267F},   // wheelchair symbol
+	{0x2693, 0x2693},   // anchor
+	{0x26A1, 0x26A1},   // high voltage
+	{0x26AA, 0x26AB},   // medium circles
+	{0x26BD, 0x26BE},   // soccer ball, baseball
+	{0x26C4, 0x26C5},   // snowman, sun behind cloud
+	{0x26CE, 0x26CE},   // ophiuchus
+	{0x26D4, 0x26D4},   // no entry
+	{0x26EA, 0x26EA},   // church
+	{0x26F2, 0x26F3},   // fountain, flag in hole
+	{0x26F5, 0x26F5},   // sailboat
+	{0x26FA, 0x26FA},   // tent
+	{0x26FD, 0x26FD},   // fuel pump
+	{0x2705, 0x2705},   // check mark button
+	{0x270A, 0x270B},   // raised fists
+	{0x2728, 0x2728},   // sparkles
+	{0x274C, 0x274C},   // cross mark
+	{0x274E, 0x274E},   // cross mark button
+	{0x2753, 0x2755},   // question and exclamation marks
+	{0x2757, 0x2757},   // heavy exclamation mark
+	{0x2795, 0x2797},   // heavy plus, minus, division
+	{0x27B0, 0x27B0},   // curly loop
+	{0x27BF, 0x27BF},   // double curly loop
+	{0x2B1B, 0x2B1C},   // large squares
+	{0x2B50, 0x2B50},   // star
+	{0x2B55, 0x2B55},   // heavy circle
+	{0x2E80, 0x303E},   // CJK radicals, symbols and punctuation
+	{0x3041, 0x33FF},   // kana, bopomofo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x16FE0, 0x16FE4}, // ideographic symbols
+	{0x17000, 0x18AFF}, // Tangut
+	{0x1B000, 0x1B2FF}, // kana supplement and extensions
+	{0x1F004, 0x1F004}, // mahjong tile
+	{0x1F0CF, 0x1F0CF}, // joker
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // squared words
+	{0x1F200, 0x1F251}, // enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F7E0, 0x1F7EB}, // large colored circles and squares
+	{0x1F90C, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended A
+	{0x20000, 0x2FFFD}, // CJK extension B and beyond
+	{0x30000, 0x3FFFD}, // CJK extension G
+}
+
+// RuneWidth returns the number of columns a terminal uses to display r:
+// 0 for combining marks and zero-width characters, 2 for wide characters
+// and 1 otherwise
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0x200B || r == 0x200C || r == 0x200D || r == 0x2060 || r == 0xFEFF:
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return 0
+	}
+	for _, w := range wideRanges {
+		if r < w.lo {
+			break
+		}
+		if r <= w.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// DisplayWidth returns the number of columns s occupies once its tabs are
+// expanded to the next multiple of tabWidth. Bytes that are not valid
+// UTF-8 count for one column each.
+func DisplayWidth(s string, tabWidth int) int {
+	return Column(s, len(s), tabWidth) - 1
+}
+
+// Column returns the 1-based display column at which the byte at offset
+// starts in line
+func Column(line string, offset, tabWidth int) int {
+	if tabWidth < 1 {
+		tabWidth = 1
+	}
+	col := 0
+	for i := 0; i < offset && i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case r == '\t':
+			col += tabWidth - col%tabWidth
+		case r == utf8.RuneError && size == 1:
+			col++
+		default:
+			col += RuneWidth(r)
+		}
+		i += size
+	}
+	return col + 1
+}
@@ -0,0 +1,198 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ConfigFilename is the configuration file looked up in the working directory
+const ConfigFilename = ".gonana.json"
+
+// ProfileOfficial selects the official coding style, which indents with
+// 4 spaces instead of tabs
+const ProfileOfficial = "official"
+
+// Config holds the user-tunable settings of the checker
+type Config struct {
+	// Naming maps a symbol kind (variable, parameter, global, typedef,
+	// struct, union, enum, enum_constant) to the pattern its names must
+	// follow. See MatchesNamingPattern for the pattern syntax.
+	Naming map[string]string `json:"naming"`
+
+	// MaxFunctions limits the number of function definitions per file and
+	// MaxExportedFunctions the number of non-static ones, main excluded
+	MaxFunctions         int `json:"max_functions"`
+	MaxExportedFunctions int `json:"max_exported_functions"`
+
+	// AllowedFunctions, when not empty, is the exhaustive list of external
+	// functions a project may call; ForbiddenFunctions are always rejected.
+	// AllowedHeaders and ForbiddenHeaders do the same for system headers.
+	AllowedFunctions   []string `json:"allowed_functions"`
+	ForbiddenFunctions []string `json:"forbidden_functions"`
+	AllowedHeaders     []string `json:"allowed_headers"`
+	ForbiddenHeaders   []string `json:"forbidden_headers"`
+
+	// TabWidth is the distance between tab stops used to measure columns
+	// and MaxLineLength the number of columns a line may span
+	TabWidth      int `json:"tab_width"`
+	MaxLineLength int `json:"max_line_length"`
+
+	// AllowedNumbers lists the numeric literals accepted in code on top of
+	// 0, 1 and -1, such as "2" or "0x7F"
+	AllowedNumbers []string `json:"allowed_numbers"`
+
+	// AllowedComments lists the comments accepted inside function bodies
+	// just before a case label, such as "fallthrough", compared without
+	// delimiters nor case
+	AllowedComments []string `json:"allowed_comments"`
+
+	// SourceDirs lists the directories, relative to the analyzed root, that
+	// .c files must be placed in. The check is disabled when empty.
+	SourceDirs []string `json:"source_dirs"`
+
+	// MaxComplexity, MaxNesting and MaxStatements are the per-function
+	// metric thresholds; 0 disables a threshold
+	MaxComplexity int `json:"max_complexity"`
+	MaxNesting    int `json:"max_nesting"`
+	MaxStatements int `json:"max_statements"`
+
+	// Profile selects a variant of the coding style: "" for the default
+	// one, or ProfileOfficial
+	Profile string `json:"profile"`
+}
+
+// IndentUnit returns the whitespace of one indentation level: a tab, or 4
+// spaces under the official profile
+func (c *Config) IndentUnit() string {
+	if c.Profile == ProfileOfficial {
+		return "    "
+	}
+	return "\t"
+}
+
+// IsForbidden reports whether an external symbol of the given kind
+// ("function" or "header") is rejected by the allow and deny lists
+func (c *Config) IsForbidden(kind, name string) bool {
+	allowed, forbidden := c.AllowedFunctions, c.ForbiddenFunctions
+	if kind == "header" {
+		allowed, forbidden = c.AllowedHeaders, c.ForbiddenHeaders
+	}
+	for _, f := range forbidden {
+		if f == name {
+			return true
+		}
+	}
+	if len(allowed) == 0 {
+		return false
+	}
+	for _, a := range allowed {
+		if a == name {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultConfig returns the settings matching the Epitech coding style
+func DefaultConfig() *Config {
+	return &Config{
+		Naming: map[string]string{
+			"variable":      "snake_case",
+			"parameter":     "snake_case",
+			"global":        "snake_case",
+			"typedef":       "snake_case_t",
+			"struct":        "snake_case",
+			"union":         "snake_case",
+			"enum":          "snake_case",
+			"enum_constant": "SCREAMING_SNAKE_CASE",
+		},
+		AllowedComments:      []string{"fallthrough"},
+		MaxFunctions:         10,
+		MaxExportedFunctions: 5,
+		TabWidth:             4,
+		MaxLineLength:        80,
+		MaxComplexity:        10,
+		MaxNesting:           3,
+	}
+}
+
+// LoadConfig reads a JSON configuration file. Settings missing from the
+// file keep their default value.
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoding over the defaults keeps the fields and map entries the file
+	// does not mention
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Profile != "" && cfg.Profile != ProfileOfficial {
+		return nil, fmt.Errorf("unknown profile '%s'", cfg.Profile)
+	}
+	kinds := make([]string, 0, len(cfg.Naming))
+	for kind := range cfg.Naming {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		if _, err := namingRegexp(cfg.Naming[kind]); err != nil {
+			return nil, fmt.Errorf("invalid naming pattern for '%s': %v", kind, err)
+		}
+	}
+	return cfg, nil
+}
+
+// MatchesNamingPattern reports whether name follows pattern. A pattern is
+// a casing style (snake_case or SCREAMING_SNAKE_CASE) with an optional
+// literal prefix and suffix, such as "g_snake_case" or "snake_case_t".
+// "any" or an empty pattern accepts every name; any other pattern is
+// treated as a regular expression matching the whole name.
+func MatchesNamingPattern(name, pattern string) bool {
+	if pattern == "" || pattern == "any" {
+		return true
+	}
+
+	styles := []struct {
+		token string
+		check func(string) bool
+	}{
+		{"SCREAMING_SNAKE_CASE", IsScreamingSnakeCase},
+		{"snake_case", IsSnakeCase},
+	}
+	for _, style := range styles {
+		idx := strings.Index(pattern, style.token)
+		if idx < 0 {
+			continue
+		}
+		prefix, suffix := pattern[:idx], pattern[idx+len(style.token):]
+		if len(name) <= len(prefix)+len(suffix) ||
+			!strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			return false
+		}
+		return style.check(name[len(prefix) : len(name)-len(suffix)])
+	}
+
+	re, err := namingRegexp(pattern)
+	if err != nil || re == nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// namingRegexp compiles a naming pattern that is a regular expression,
+// and returns nil for "any" and the casing styles
+func namingRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" || pattern == "any" ||
+		strings.Contains(pattern, "snake_case") || strings.Contains(pattern, "SCREAMING_SNAKE_CASE") {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
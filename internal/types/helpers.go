@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"epicstyle/internal/parser"
 )
 
 // IsSnakeCase checks if a string is in snake_case format
@@ -40,64 +42,63 @@ func IsScreamingSnakeCase(s string) bool {
 
 // ExtractFunctions parses lines of C code to extract function information
 func ExtractFunctions(lines []string) []FunctionInfo {
-	var functions []FunctionInfo
-	var currentFunc *FunctionInfo
-	braceCount := 0
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Simple function detection
-		if strings.Contains(trimmed, "(") && strings.Contains(trimmed, ")") &&
-			(strings.Contains(trimmed, "{") || (i+1 < len(lines) && strings.Contains(strings.TrimSpace(lines[i+1]), "{"))) {
-			if !strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "/*") &&
-				!strings.Contains(trimmed, "if") && !strings.Contains(trimmed, "while") &&
-				!strings.Contains(trimmed, "for") && !strings.Contains(trimmed, "switch") {
-
-				// Extract function name
-				parenPos := strings.Index(trimmed, "(")
-				if parenPos > 0 {
-					funcPart := trimmed[:parenPos]
-					parts := strings.Fields(funcPart)
-					if len(parts) > 0 {
-						funcName := parts[len(parts)-1]
-						if strings.Contains(funcName, "*") {
-							funcName = strings.TrimLeft(funcName, "*")
-						}
-
-						// Count parameters
-						paramPart := trimmed[parenPos+1:]
-						closeParenPos := strings.Index(paramPart, ")")
-						if closeParenPos > 0 {
-							params := paramPart[:closeParenPos]
-							paramCount := 0
-							if strings.TrimSpace(params) != "" && strings.TrimSpace(params) != "void" {
-								paramCount = strings.Count(params, ",") + 1
-							}
+	return UnitFunctions(parser.Parse(lines))
+}
 
-							currentFunc = &FunctionInfo{
-								Name:       funcName,
-								StartLine:  i + 1,
-								ParamCount: paramCount,
-							}
-						}
-					}
-				}
-			}
+// UnitFunctions returns the function definitions of a parsed unit
+func UnitFunctions(unit *parser.Unit) []FunctionInfo {
+	functions := make([]FunctionInfo, 0, len(unit.Functions))
+	for _, fn := range unit.Functions {
+		info := FunctionInfo{
+			Name:        fn.Name,
+			StartLine:   fn.Line,
+			EndLine:     fn.EndLine,
+			ParamCount:  len(fn.Params),
+			Static:      fn.Static,
+			EmptyParams: unit.Next(fn.ParamOpen+1) == fn.ParamClose,
+		}
+		for _, p := range fn.Params {
+			info.Params = append(info.Params, ParamInfo{
+				Type:     p.Type,
+				Name:     p.Name,
+				Pointers: p.Pointers,
+				Array:    p.Array,
+				Line:     unit.Tokens[p.Start].Line,
+			})
 		}
+		functions = append(functions, info)
+	}
+	return functions
+}
 
-		// Count braces to find function end
-		braceCount += strings.Count(line, "{")
-		braceCount -= strings.Count(line, "}")
+// File kinds returned by FileKind
+const (
+	KindC        = ""
+	KindMakefile = "makefile"
+)
 
-		if currentFunc != nil && braceCount == 0 && strings.Contains(line, "}") {
-			currentFunc.EndLine = i + 1
-			functions = append(functions, *currentFunc)
-			currentFunc = nil
-		}
+// FileKind tells whether a file is a Makefile (Makefile, makefile,
+// GNUmakefile or *.mk) or a C source or header
+func FileKind(filename string) string {
+	base := filepath.Base(filename)
+	switch {
+	case base == "Makefile", base == "makefile", base == "GNUmakefile", filepath.Ext(base) == ".mk":
+		return KindMakefile
 	}
+	return KindC
+}
 
-	return functions
+// SplitLines splits file content into lines. CRLF and lone CR line endings
+// are accepted, and the newline terminating the last line does not start
+// an extra empty line.
+func SplitLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	lines := strings.Split(content, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
 }
 
 // ToSnakeCase converts a string to snake_case
@@ -113,7 +114,23 @@ func ToSnakeCase(s string) string {
 	return strings.ToLower(result.String())
 }
 
-// CollectCFiles collects all C files from the given path
+// ToScreamingSnakeCase converts a name to SCREAMING_SNAKE_CASE, starting
+// a word at each uppercase letter following a lowercase one or a digit
+func ToScreamingSnakeCase(s string) string {
+	var result strings.Builder
+	prev := rune(0)
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' && ((prev >= 'a' && prev <= 'z') || (prev >= '0' && prev <= '9')) {
+			result.WriteRune('_')
+		}
+		result.WriteRune(r)
+		prev = r
+	}
+	return strings.ToUpper(result.String())
+}
+
+// CollectCFiles collects all C files from the given path, skipping hidden
+// directories such as .git and the .gonana backups
 func CollectCFiles(path string) ([]string, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -127,6 +144,9 @@ func CollectCFiles(path string) ([]string, error) {
 			if err != nil {
 				return err
 			}
+			if info.IsDir() && p != path && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
 			ext := filepath.Ext(p)
 			if !info.IsDir() && (ext == ".c" || ext == ".h") {
 				files = append(files, p)
@@ -1,12 +1,17 @@
 package types
 
+import "epicstyle/internal/parser"
+
 // Violation represents a single coding style violation
 type Violation struct {
 	Rule        string `json:"rule"`
 	Message     string `json:"message"`
 	Line        int    `json:"line"`
+	Column      int    `json:"column,omitempty"` // 1-based display column, 0 when not relevant
+	File        string `json:"file,omitempty"`   // path of a repository violation, relative to the analyzed root
 	Severity    string `json:"severity"`
 	Description string `json:"description"`
+	Fixable     bool   `json:"fixable"` // corrected by the fix provider of its rule
 }
 
 // FileResult contains the analysis results for a single file
@@ -15,23 +20,115 @@ type FileResult struct {
 	Violations []Violation `json:"violations"`
 	Score      float64     `json:"score"`
 	LineCount  int         `json:"line_count"`
+	External   []SymbolRef `json:"-"`
+	// Metrics describes each function defined in the file
+	Metrics []FunctionMetrics `json:"metrics,omitempty"`
+}
+
+// FunctionMetrics holds the size and complexity measures of a function
+type FunctionMetrics struct {
+	Name       string `json:"name"`
+	Line       int    `json:"line"`
+	Complexity int    `json:"complexity"` // cyclomatic complexity
+	Nesting    int    `json:"nesting"`    // deepest control statement nesting
+	Statements int    `json:"statements"`
+	Params     int    `json:"params"`
+	Lines      int    `json:"lines"`
+}
+
+// SymbolRef is a use of an external symbol: a call to a function defined
+// outside the analyzed files, or the inclusion of a system header
+type SymbolRef struct {
+	Name string
+	Kind string // "function" or "header"
+	Line int
+}
+
+// SymbolUsage summarizes the uses of an external symbol across all files
+type SymbolUsage struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Count     int      `json:"count"`
+	Files     []string `json:"files"`
+	Forbidden bool     `json:"forbidden"`
 }
 
 // Report contains the overall analysis results
 type Report struct {
-	Files           []FileResult `json:"files"`
-	TotalScore      float64      `json:"total_score"`
-	TotalFiles      int          `json:"total_files"`
-	TotalLines      int          `json:"total_lines"`
-	TotalViolations int          `json:"total_violations"`
-	CleanFiles      int          `json:"clean_files"`
+	Files           []FileResult  `json:"files"`
+	TotalScore      float64       `json:"total_score"`
+	TotalFiles      int           `json:"total_files"`
+	TotalLines      int           `json:"total_lines"`
+	TotalViolations int           `json:"total_violations"`
+	CleanFiles      int           `json:"clean_files"`
+	ExternalSymbols []SymbolUsage `json:"external_symbols"`
+	// Repository holds the violations of the project tree itself, which do
+	// not belong to an analyzed file
+	Repository []Violation `json:"repository_violations"`
+}
+
+// ProjectAnalysis describes the analyzed tree for project-level rules
+type ProjectAnalysis struct {
+	Root   string      // directory given to the analyzer
+	Files  []string    // C sources and headers analyzed
+	Tree   []TreeEntry // every file and directory below Root, hidden ones excluded
+	Config *Config
+
+	// Sources holds the parsed C sources and headers, for cross-file rules
+	Sources []*FileAnalysis
+}
+
+// TreeEntry is a file or directory of the analyzed tree
+type TreeEntry struct {
+	Path string // slash-separated, relative to the root
+	Dir  bool
+}
+
+// Settings returns the configuration the project is checked with
+func (p *ProjectAnalysis) Settings() *Config {
+	if p.Config == nil {
+		p.Config = DefaultConfig()
+	}
+	return p.Config
 }
 
 // FileAnalysis contains the parsed content of a file
 type FileAnalysis struct {
 	Filename  string
+	Content   []byte // raw file content, line endings and encoding untouched
 	Lines     []string
 	Functions []FunctionInfo
+	Config    *Config
+	// ProjectSymbols holds the functions and macros defined anywhere in
+	// the analyzed files; nil when the file is analyzed on its own
+	ProjectSymbols map[string]bool
+	unit           *parser.Unit
+	makefile       *parser.Makefile
+}
+
+// Settings returns the configuration the file is checked with, falling
+// back to the defaults when none was given
+func (a *FileAnalysis) Settings() *Config {
+	if a.Config == nil {
+		a.Config = DefaultConfig()
+	}
+	return a.Config
+}
+
+// Unit returns the parsed form of the file, parsing Lines on first use
+func (a *FileAnalysis) Unit() *parser.Unit {
+	if a.unit == nil {
+		a.unit = parser.Parse(a.Lines)
+	}
+	return a.unit
+}
+
+// Makefile returns the parsed Makefile, parsing the lines on first use
+func (a *FileAnalysis) Makefile() *parser.Makefile {
+	if a.makefile == nil {
+		a.makefile = parser.ParseMakefile(a.Lines)
+	}
+	return a.makefile
 }
 
 // FunctionInfo contains information about a function in the code
@@ -40,6 +137,35 @@ type FunctionInfo struct {
 	StartLine  int
 	EndLine    int
 	ParamCount int
+	Static     bool
+	// EmptyParams is set for a parameter list written "()" instead of
+	// "(void)"; both have no Params
+	EmptyParams bool
+	Params      []ParamInfo
+}
+
+// ParamInfo describes a function parameter
+type ParamInfo struct {
+	Type     string // base type, e.g. "char" or "struct node"
+	Name     string // empty for unnamed parameters
+	Pointers int
+	Array    bool
+	Line     int
+}
+
+// ProjectRule is a rule checking the analyzed tree as a whole rather than
+// a single file
+type ProjectRule struct {
+	Code        string
+	Name        string
+	Description string
+	Severity    string
+	Level       int
+	// CrossFile rules compare the analyzed sources with each other and
+	// only run when the project phase is enabled. Their violations belong
+	// to the file named by Violation.File; the others describe the tree.
+	CrossFile bool
+	Check     func(*ProjectAnalysis) []Violation
 }
 
 // Rule represents a code style rule with its checking logic
@@ -49,5 +175,34 @@ type Rule struct {
 	Description string
 	Severity    string
 	Level       int
+	Kind        string // kind of file checked, see FileKind; "" for C files
 	Check       func(*FileAnalysis, string, int) []Violation
+	Fix         FixProvider // nil when the violations are not fixed automatically
+}
+
+// Edit replaces the bytes from Start to End of the raw content of a file
+// with Text; Start equals End for an insertion
+type Edit struct {
+	Start int
+	End   int
+	Text  string
 }
+
+// Fix is a correction of a rule's violation. Its edits do not overlap. A
+// fix with neither edits nor rename reports a violation left unfixed on
+// purpose, Description giving the reason.
+type Fix struct {
+	Rule        string
+	Description string
+	Line        int    // line of the violation, 0 for the whole file
+	Edits       []Edit // byte ranges of the analyzed content to replace
+	Rename      string // new name of the file or symbol, applied project-wide
+}
+
+// Fixes reports whether the fix corrects its violation
+func (f Fix) Fixes() bool {
+	return len(f.Edits) > 0 || f.Rename != ""
+}
+
+// FixProvider returns the fixes of a rule's violations in a file
+type FixProvider func(*FileAnalysis) []Fix